@@ -0,0 +1,231 @@
+package oqs
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+/**************** Aliases ****************/
+
+// Alias maps a historical liboqs algorithm name to its successor.
+type Alias struct {
+	Name        string // historical name, e.g. "Kyber768"
+	CurrentName string // name of the successor, e.g. "ML-KEM-768"
+	// WireCompatible is true if the successor is the same algorithm under a
+	// new name, hence keys, ciphertexts and signatures interoperate, and false
+	// if the successor is a different algorithm, e.g. the NIST standard that
+	// superseded a submission.
+	WireCompatible bool
+	Note           string
+}
+
+// kemAliases and sigAliases are the alias tables, see KEMAliases and
+// SigAliases.
+var (
+	kemAliases = []Alias{
+		{"Kyber512", "ML-KEM-512", false, kyberNote},
+		{"Kyber768", "ML-KEM-768", false, kyberNote},
+		{"Kyber1024", "ML-KEM-1024", false, kyberNote},
+	}
+	sigAliases = append([]Alias{
+		{"Dilithium2", "ML-DSA-44", false, dilithiumNote},
+		{"Dilithium3", "ML-DSA-65", false, dilithiumNote},
+		{"Dilithium5", "ML-DSA-87", false, dilithiumNote},
+	}, sphincsAliases()...)
+)
+
+const (
+	kyberNote = "FIPS 203 changed the key generation and the derivation of " +
+		"the shared secret"
+	dilithiumNote = "FIPS 204 changed the key and signature encodings and " +
+		"the hashing of the message"
+	sphincsRenameNote = "renamed in liboqs 0.8.0"
+	sphincsSHA2Note   = "SPHINCS+ 3.1, adopted in liboqs 0.8.0, changed " +
+		"the SHA-2 message hash, and uses SHA-512 at levels 3 and 5"
+	sphincsNote = "FIPS 205 changed the hashing of the message"
+)
+
+// sphincsAliases returns the aliases of the SPHINCS+ parameter sets, which
+// were renamed in liboqs 0.8.0 and later superseded by SLH-DSA. The rename of
+// the SHAKE256 parameter sets is wire-compatible, whereas the SHA256 ones were
+// replaced by the different SHA-2 instantiation of SPHINCS+ 3.1.
+func sphincsAliases() []Alias {
+	var aliases []Alias
+	for _, hash := range []struct {
+		old, current   string
+		wireCompatible bool
+		note           string
+	}{
+		{"SHA256", "SHA2", false, sphincsSHA2Note},
+		{"SHAKE256", "SHAKE", true, sphincsRenameNote},
+	} {
+		for _, size := range []string{"128", "192", "256"} {
+			for _, variant := range []string{"f", "s"} {
+				current := "SPHINCS+-" + hash.current + "-" + size + variant +
+					"-simple"
+				aliases = append(aliases,
+					Alias{"SPHINCS+-" + hash.old + "-" + size + variant +
+						"-simple", current, hash.wireCompatible, hash.note},
+					Alias{current, "SLH_DSA_PURE_" + hash.current + "_" +
+						size + strings.ToUpper(variant), false, sphincsNote})
+			}
+		}
+	}
+	return aliases
+}
+
+// KEMAliases returns the historical KEM names known to the wrapper.
+func KEMAliases() []Alias {
+	return append([]Alias(nil), kemAliases...)
+}
+
+// SigAliases returns the historical signature algorithm names known to the
+// wrapper.
+func SigAliases() []Alias {
+	return append([]Alias(nil), sigAliases...)
+}
+
+// lookupAlias returns the alias of algName in aliases, if any.
+func lookupAlias(aliases []Alias, algName string) (Alias, bool) {
+	for _, alias := range aliases {
+		if alias.Name == algName {
+			return alias, true
+		}
+	}
+	return Alias{}, false
+}
+
+// canonicalName follows the aliases of algName to its current name.
+func canonicalName(aliases []Alias, algName string) string {
+	for {
+		alias, ok := lookupAlias(aliases, algName)
+		if !ok {
+			return algName
+		}
+		algName = alias.CurrentName
+	}
+}
+
+// CanonicalKEMName returns the current name of a KEM, e.g. "ML-KEM-768" for
+// "Kyber768", or algName itself if it is not a historical name. The current
+// algorithm may not be wire-compatible with the historical one, see
+// KEMAliases and KeyMaterialReusable.
+func CanonicalKEMName(algName string) string {
+	return canonicalName(kemAliases, algName)
+}
+
+// CanonicalSigName returns the current name of a signature algorithm, e.g.
+// "ML-DSA-65" for "Dilithium3", or algName itself if it is not a historical
+// name. The current algorithm may not be wire-compatible with the historical
+// one, see SigAliases and KeyMaterialReusable.
+func CanonicalSigName(algName string) string {
+	return canonicalName(sigAliases, algName)
+}
+
+// KeyMaterialReusable reports whether key material generated for the
+// algorithm oldName can be used with the algorithm newName, i.e., whether
+// both names are the same, or newName is reached from oldName through
+// wire-compatible renames only. Otherwise, the keys must be regenerated, and
+// reason explains why.
+func KeyMaterialReusable(oldName, newName string) (reusable bool,
+	reason string,
+) {
+	if oldName == newName {
+		return true, ""
+	}
+	for _, aliases := range [][]Alias{kemAliases, sigAliases} {
+		reason := ""
+		for algName := oldName; ; {
+			alias, ok := lookupAlias(aliases, algName)
+			if !ok {
+				break
+			}
+			if !alias.WireCompatible && reason == "" {
+				reason = `"` + alias.Name + `" and "` + alias.CurrentName +
+					`" are not wire-compatible: ` + alias.Note
+			}
+			if alias.CurrentName == newName {
+				return reason == "", reason
+			}
+			algName = alias.CurrentName
+		}
+	}
+
+	return false, `"` + newName + `" is not a successor of "` + oldName + `"`
+}
+
+// DeprecationWarning is reported to the handler set with
+// SetDeprecationHandler when KeyEncapsulation.Init or Signature.Init is called
+// with a historical algorithm name.
+type DeprecationWarning struct {
+	Algorithm   string // the historical name passed to Init
+	CurrentName string // the current name, see CanonicalKEMName
+	// Resolved is true if Init looked the algorithm up under a
+	// wire-compatible current name, since the historical name is not enabled
+	// by liboqs.
+	Resolved bool
+	Message  string
+}
+
+// deprecationHandler is the handler set with SetDeprecationHandler, or nil.
+var deprecationHandler atomic.Pointer[func(DeprecationWarning)]
+
+// SetDeprecationHandler sets the function called with a DeprecationWarning
+// whenever a historical algorithm name is passed to KeyEncapsulation.Init or
+// Signature.Init. A nil fn disables the warnings, which is the default.
+func SetDeprecationHandler(fn func(DeprecationWarning)) {
+	if fn == nil {
+		deprecationHandler.Store(nil)
+		return
+	}
+	deprecationHandler.Store(&fn)
+}
+
+// resolveAlias returns the name Init uses for algName, i.e., algName itself,
+// or, if it is not enabled, the name reached through wire-compatible renames
+// only. A historical algName is reported to the deprecation handler. enabled
+// reports whether an algorithm is enabled by liboqs.
+func resolveAlias(aliases []Alias, algName string,
+	enabled func(string) bool,
+) string {
+	alias, ok := lookupAlias(aliases, algName)
+	if !ok {
+		return algName
+	}
+
+	resolved := algName
+	for ok && alias.WireCompatible && !enabled(resolved) {
+		resolved = alias.CurrentName
+		alias, ok = lookupAlias(aliases, resolved)
+	}
+	if handler := deprecationHandler.Load(); handler != nil {
+		current := canonicalName(aliases, algName)
+		message := `"` + algName + `" is deprecated, use "` + current + `"`
+		if reusable, reason := KeyMaterialReusable(algName,
+			current); !reusable {
+			message += ", and regenerate the keys: " + reason
+		}
+		(*handler)(DeprecationWarning{
+			Algorithm:   algName,
+			CurrentName: current,
+			Resolved:    resolved != algName,
+			Message:     message,
+		})
+	}
+	return resolved
+}
+
+// aliasHint returns a hint on the current name of a historical algName that is
+// not supported, to be appended to the error returned by Init.
+func aliasHint(aliases []Alias, algName string) string {
+	current := canonicalName(aliases, algName)
+	if current == algName {
+		return ""
+	}
+	if reusable, _ := KeyMaterialReusable(algName, current); reusable {
+		return `, its current name is "` + current + `"`
+	}
+	return `, its successor "` + current + `" is not wire-compatible`
+}
+
+/**************** END Aliases ****************/
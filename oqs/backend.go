@@ -0,0 +1,83 @@
+package oqs
+
+/**************** Backends ****************/
+
+// The cryptographic operations are delegated to a backend selected at build
+// time. The default backend (backend_liboqs.go) calls into liboqs via cgo. The
+// pure-Go backend (backend_purego.go), selected by the oqs_purego build tag,
+// implements ML-KEM and ML-DSA on top of the Go standard library and does not
+// require cgo. With the oqs_dlopen build tag, the liboqs backend loads liboqs
+// at runtime rather than linking against it (backend_liboqs_dlopen.go).
+// Without cgo and without the oqs_purego tag, the no-cgo backend
+// (backend_nocgo.go) enables no algorithm. The backends without liboqs share
+// backend_noliboqs.go.
+// Besides the types below, each backend provides the following functions,
+// where a non-nil rand is the per-object source of randomness set with
+// WithRandomReader, and randomScope implements WithRandomSource:
+//
+//	backendName() string
+//	backendVersion() string
+//	initBackend()
+//	destroyBackend()
+//	threadStop()
+//	memCleanse(v []byte)
+//	kemAlgCount() int
+//	kemAlgIdentifier(algID int) string
+//	kemAlgIsEnabled(algName string) bool
+//	newKEMBackend(algName string, rand io.Reader) (kemBackend, error)
+//	sigAlgCount() int
+//	sigAlgIdentifier(algID int) string
+//	sigAlgIsEnabled(algName string) bool
+//	newSigBackend(algName string, rand io.Reader) (sigBackend, error)
+//	randomBytes(randomArray []byte) error
+//	randomScope(r io.Reader, fn func()) error
+//	randomBytesSwitchAlgorithm(algName string) error
+//	randomBytesCustomAlgorithm()
+//	loadLibrary(path string) error
+//	libraryPath() string
+//	libraryError() error
+//	headerVersion() string
+//	headerBuildTarget() string
+//	headerBuildFlags() []string
+//	cpuFeatures() map[string]bool
+//	compiledVariants() map[string]string
+//	runtimeDispatch() bool
+
+// kemBackend is the backend implementation of a KEM algorithm. The buffers
+// passed to its methods are allocated by the caller and have the lengths given
+// by details().
+type kemBackend interface {
+	details() KeyEncapsulationDetails
+	keypair(publicKey, secretKey []byte) error
+	encaps(ciphertext, sharedSecret, publicKey []byte) error
+	decaps(sharedSecret, ciphertext, secretKey []byte) error
+	free()
+}
+
+// sigBackend is the backend implementation of a signature algorithm. The
+// buffers passed to its methods are allocated by the caller and have the
+// lengths given by details(); the signature buffer has the maximum signature
+// length, and the sign methods return the actual length of the signature.
+// signDeterministic is only called for ML-DSA, and implements the deterministic
+// variant of FIPS 204, Algorithm 2.
+type sigBackend interface {
+	details() SignatureDetails
+	keypair(publicKey, secretKey []byte) error
+	sign(signature, message, secretKey []byte) (int, error)
+	signWithCtxStr(signature, message, context, secretKey []byte) (int, error)
+	signDeterministic(signature, message, context, secretKey []byte) (int,
+		error)
+	verify(message, signature, publicKey []byte) bool
+	verifyWithCtxStr(message, signature, context, publicKey []byte) bool
+	free()
+}
+
+// Backend returns the name of the backend the package was built with, i.e.,
+// "liboqs" for the default cgo backend, "purego" for the pure-Go backend
+// selected by the oqs_purego build tag, or "nocgo" when built without cgo and
+// without the oqs_purego tag, in which case no algorithm is enabled.
+func Backend() string {
+	return backendName()
+}
+
+/**************** END Backends ****************/
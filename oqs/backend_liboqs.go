@@ -0,0 +1,422 @@
+//go:build !oqs_purego
+
+package oqs
+
+/*
+#include <stdlib.h>
+#include "oqsgo.h"
+typedef void (*rand_algorithm_ptr)(uint8_t*, size_t);
+void randAlgorithmPtr_cgo(uint8_t*, size_t);
+*/
+import "C"
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+/**************** liboqs backend ****************/
+
+// backendName returns the name of the liboqs backend.
+func backendName() string {
+	return "liboqs"
+}
+
+// backendVersion returns the liboqs version string.
+func backendVersion() string {
+	return C.GoString(C.oqsgo_OQS_version())
+}
+
+// memCleanse zeroes v with OQS_MEM_cleanse().
+func memCleanse(v []byte) {
+	C.oqsgo_OQS_MEM_cleanse(unsafe.Pointer(&v[0]), C.size_t(len(v)))
+}
+
+// initBackend initializes liboqs with OQS_init().
+func initBackend() {
+	C.oqsgo_OQS_init()
+}
+
+// destroyBackend releases the global resources of liboqs with OQS_destroy().
+func destroyBackend() {
+	C.oqsgo_OQS_destroy()
+}
+
+// threadStop releases the resources of liboqs for the calling thread with
+// OQS_thread_stop().
+func threadStop() {
+	C.oqsgo_OQS_thread_stop()
+}
+
+// bytesPtr returns a pointer to the first element of b, or NULL if b is empty,
+// so that zero-length messages, signatures and context strings can be handed
+// over to liboqs.
+func bytesPtr(b []byte) *C.uint8_t {
+	if len(b) == 0 {
+		return nil
+	}
+	return (*C.uint8_t)(unsafe.Pointer(&b[0]))
+}
+
+/**************** END liboqs backend ****************/
+
+/**************** liboqs KEMs ****************/
+
+func kemAlgCount() int {
+	return int(C.oqsgo_OQS_KEM_alg_count())
+}
+
+func kemAlgIdentifier(algID int) string {
+	return C.GoString(C.oqsgo_OQS_KEM_alg_identifier(C.size_t(algID)))
+}
+
+func kemAlgIsEnabled(algName string) bool {
+	cAlgName := C.CString(algName)
+	defer C.free(unsafe.Pointer(cAlgName))
+	return C.oqsgo_OQS_KEM_alg_is_enabled(cAlgName) != 0
+}
+
+// liboqsKEM implements kemBackend on top of an OQS_KEM.
+type liboqsKEM struct {
+	kem  *C.OQS_KEM
+	rand *randSource
+}
+
+func newKEMBackend(algName string, rand io.Reader) (kemBackend, error) {
+	source, err := newRandSource(rand, "per-object random readers")
+	if err != nil {
+		return nil, err
+	}
+	cAlgName := C.CString(algName)
+	defer C.free(unsafe.Pointer(cAlgName))
+	kem := C.oqsgo_OQS_KEM_new(cAlgName)
+	if kem == nil {
+		source.free()
+		return nil, errors.New(`can not instantiate "` + algName + `" KEM`)
+	}
+	return &liboqsKEM{kem: kem, rand: source}, nil
+}
+
+func (b *liboqsKEM) details() KeyEncapsulationDetails {
+	return KeyEncapsulationDetails{
+		Name:               C.GoString(b.kem.method_name),
+		Version:            C.GoString(b.kem.alg_version),
+		ClaimedNISTLevel:   int(b.kem.claimed_nist_level),
+		IsINDCCA:           bool(b.kem.ind_cca),
+		LengthPublicKey:    int(b.kem.length_public_key),
+		LengthSecretKey:    int(b.kem.length_secret_key),
+		LengthCiphertext:   int(b.kem.length_ciphertext),
+		LengthSharedSecret: int(b.kem.length_shared_secret),
+	}
+}
+
+func (b *liboqsKEM) keypair(publicKey, secretKey []byte) error {
+	var rv C.OQS_STATUS
+	if err := b.rand.do(func() {
+		rv = C.oqsgo_OQS_KEM_keypair(
+			b.kem,
+			(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
+			(*C.uint8_t)(unsafe.Pointer(&secretKey[0])),
+		)
+	}); err != nil {
+		memCleanse(secretKey)
+		return err
+	}
+	if rv != C.OQS_SUCCESS {
+		return errors.New("can not generate keypair")
+	}
+	return nil
+}
+
+func (b *liboqsKEM) encaps(ciphertext, sharedSecret, publicKey []byte) error {
+	var rv C.OQS_STATUS
+	if err := b.rand.do(func() {
+		rv = C.oqsgo_OQS_KEM_encaps(
+			b.kem,
+			(*C.uint8_t)(unsafe.Pointer(&ciphertext[0])),
+			(*C.uint8_t)(unsafe.Pointer(&sharedSecret[0])),
+			(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
+		)
+	}); err != nil {
+		memCleanse(sharedSecret)
+		return err
+	}
+	if rv != C.OQS_SUCCESS {
+		return errors.New("can not encapsulate secret")
+	}
+	return nil
+}
+
+func (b *liboqsKEM) decaps(sharedSecret, ciphertext, secretKey []byte) error {
+	rv := C.oqsgo_OQS_KEM_decaps(
+		b.kem,
+		(*C.uint8_t)(unsafe.Pointer(&sharedSecret[0])),
+		(*C.uchar)(unsafe.Pointer(&ciphertext[0])),
+		(*C.uint8_t)(unsafe.Pointer(&secretKey[0])),
+	)
+	if rv != C.OQS_SUCCESS {
+		return errors.New("can not decapsulate secret")
+	}
+	return nil
+}
+
+func (b *liboqsKEM) free() {
+	C.oqsgo_OQS_KEM_free(b.kem)
+	b.kem = nil
+	b.rand.free()
+	b.rand = nil
+}
+
+/**************** END liboqs KEMs ****************/
+
+/**************** liboqs Sigs ****************/
+
+func sigAlgCount() int {
+	return int(C.oqsgo_OQS_SIG_alg_count())
+}
+
+func sigAlgIdentifier(algID int) string {
+	return C.GoString(C.oqsgo_OQS_SIG_alg_identifier(C.size_t(algID)))
+}
+
+func sigAlgIsEnabled(algName string) bool {
+	cAlgName := C.CString(algName)
+	defer C.free(unsafe.Pointer(cAlgName))
+	return C.oqsgo_OQS_SIG_alg_is_enabled(cAlgName) != 0
+}
+
+// liboqsSig implements sigBackend on top of an OQS_SIG.
+type liboqsSig struct {
+	sig  *C.OQS_SIG
+	rand *randSource
+}
+
+func newSigBackend(algName string, rand io.Reader) (sigBackend, error) {
+	source, err := newRandSource(rand, "per-object random readers")
+	if err != nil {
+		return nil, err
+	}
+	cAlgName := C.CString(algName)
+	defer C.free(unsafe.Pointer(cAlgName))
+	sig := C.oqsgo_OQS_SIG_new(cAlgName)
+	if sig == nil {
+		source.free()
+		return nil, errors.New(`can not instantiate "` + algName +
+			`" signature mechanism`)
+	}
+	return &liboqsSig{sig: sig, rand: source}, nil
+}
+
+// lenSigPool holds the signature lengths written by liboqs.
+var lenSigPool = sync.Pool{
+	New: func() any { return new(C.size_t) },
+}
+
+func (b *liboqsSig) details() SignatureDetails {
+	return SignatureDetails{
+		Name:               C.GoString(b.sig.method_name),
+		Version:            C.GoString(b.sig.alg_version),
+		ClaimedNISTLevel:   int(b.sig.claimed_nist_level),
+		IsEUFCMA:           bool(b.sig.euf_cma),
+		SigWithCtxSupport:  bool(b.sig.sig_with_ctx_support),
+		LengthPublicKey:    int(b.sig.length_public_key),
+		LengthSecretKey:    int(b.sig.length_secret_key),
+		MaxLengthSignature: int(b.sig.length_signature),
+	}
+}
+
+func (b *liboqsSig) keypair(publicKey, secretKey []byte) error {
+	var rv C.OQS_STATUS
+	if err := b.rand.do(func() {
+		rv = C.oqsgo_OQS_SIG_keypair(
+			b.sig,
+			(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
+			(*C.uint8_t)(unsafe.Pointer(&secretKey[0])),
+		)
+	}); err != nil {
+		memCleanse(secretKey)
+		return err
+	}
+	if rv != C.OQS_SUCCESS {
+		return errors.New("can not generate keypair")
+	}
+	return nil
+}
+
+func (b *liboqsSig) sign(signature, message, secretKey []byte) (int, error) {
+	// The signature length is pooled, as passing its address to liboqs
+	// would otherwise allocate it on every call
+	lenSig := lenSigPool.Get().(*C.size_t)
+	defer lenSigPool.Put(lenSig)
+	var rv C.OQS_STATUS
+	if err := b.rand.do(func() {
+		rv = C.oqsgo_OQS_SIG_sign(
+			b.sig,
+			(*C.uint8_t)(unsafe.Pointer(&signature[0])),
+			lenSig,
+			bytesPtr(message),
+			C.size_t(len(message)),
+			(*C.uint8_t)(unsafe.Pointer(&secretKey[0])),
+		)
+	}); err != nil {
+		return 0, err
+	}
+	if rv != C.OQS_SUCCESS {
+		return 0, errors.New("can not sign message")
+	}
+	return int(*lenSig), nil
+}
+
+func (b *liboqsSig) signWithCtxStr(signature, message, context,
+	secretKey []byte,
+) (int, error) {
+	return b.signWithCtxStrSource(b.rand, signature, message, context,
+		secretKey)
+}
+
+// signWithCtxStrSource signs with the randomness read from source, which is
+// passed explicitly rather than swapped into b, as copies of a Signature and
+// abandoned context operations may use b concurrently.
+func (b *liboqsSig) signWithCtxStrSource(source *randSource, signature,
+	message, context, secretKey []byte,
+) (int, error) {
+	// The signature length is pooled, as passing its address to liboqs
+	// would otherwise allocate it on every call
+	lenSig := lenSigPool.Get().(*C.size_t)
+	defer lenSigPool.Put(lenSig)
+	var rv C.OQS_STATUS
+	if err := source.do(func() {
+		rv = C.oqsgo_OQS_SIG_sign_with_ctx_str(
+			b.sig,
+			(*C.uint8_t)(unsafe.Pointer(&signature[0])),
+			lenSig,
+			bytesPtr(message),
+			C.size_t(len(message)),
+			bytesPtr(context),
+			C.size_t(len(context)),
+			(*C.uint8_t)(unsafe.Pointer(&secretKey[0])),
+		)
+	}); err != nil {
+		return 0, err
+	}
+	if rv != C.OQS_SUCCESS {
+		return 0, errors.New("can not sign message")
+	}
+	return int(*lenSig), nil
+}
+
+func (b *liboqsSig) signDeterministic(signature, message, context,
+	secretKey []byte,
+) (int, error) {
+	// ML-DSA draws exactly the 32-byte rnd from the RNG, which is all zeroes
+	// in the deterministic variant
+	source, err := newRandSource(zeroReader{}, "deterministic signing")
+	if err != nil {
+		return 0, err
+	}
+	defer source.free()
+	return b.signWithCtxStrSource(source, signature, message, context,
+		secretKey)
+}
+
+func (b *liboqsSig) verify(message, signature, publicKey []byte) bool {
+	rv := C.oqsgo_OQS_SIG_verify(
+		b.sig,
+		bytesPtr(message),
+		C.size_t(len(message)),
+		bytesPtr(signature),
+		C.size_t(len(signature)),
+		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
+	)
+	return rv == C.OQS_SUCCESS
+}
+
+func (b *liboqsSig) verifyWithCtxStr(message, signature, context,
+	publicKey []byte,
+) bool {
+	rv := C.oqsgo_OQS_SIG_verify_with_ctx_str(
+		b.sig,
+		bytesPtr(message),
+		C.size_t(len(message)),
+		bytesPtr(signature),
+		C.size_t(len(signature)),
+		bytesPtr(context),
+		C.size_t(len(context)),
+		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
+	)
+	return rv == C.OQS_SUCCESS
+}
+
+func (b *liboqsSig) free() {
+	C.oqsgo_OQS_SIG_free(b.sig)
+	b.sig = nil
+	b.rand.free()
+	b.rand = nil
+}
+
+/**************** END liboqs Sigs ****************/
+
+/**************** liboqs Randomness ****************/
+
+// randAlgorithmPtr is automatically invoked by RandomBytesCustomAlgorithm. When
+// invoked, the memory is provided by the caller, i.e. RandomBytes or
+// RandomBytesInPlace. Once per-object readers are in use, it also dispatches
+// to the reader of the calling thread, i.e. the one of the object or of the
+// enclosing WithRandomSource, and serves the "system" algorithm.
+//
+//export randAlgorithmPtr
+func randAlgorithmPtr(randomArray *C.uint8_t, bytesToRead C.size_t) {
+	randomSlice := unsafe.Slice((*byte)(unsafe.Pointer(randomArray)),
+		int(bytesToRead))
+	if call := currentRandCall(); call != nil {
+		call.read(randomSlice)
+	} else if callback := loadRandomCallback(); callback != nil {
+		// The callback may retain its argument, so hand over a copy
+		result := make([]byte, int(bytesToRead))
+		callback(result, int(bytesToRead))
+		copy(randomSlice, result)
+		healthTest(randomSlice)
+	} else {
+		_, _ = rand.Read(randomSlice)
+	}
+}
+
+func randomBytes(randomArray []byte) error {
+	if len(randomArray) == 0 {
+		return nil
+	}
+	var source *randSource
+	return source.do(func() {
+		C.oqsgo_OQS_randombytes((*C.uint8_t)(unsafe.Pointer(&randomArray[0])),
+			C.size_t(len(randomArray)))
+	})
+}
+
+func randomBytesSwitchAlgorithm(algName string) error {
+	if randSources > 0 {
+		// randAlgorithmPtr stays installed, and serves "system" itself, until
+		// disableRandomDispatch switches liboqs back to its system RNG
+		if !strings.EqualFold(algName, "system") {
+			return errors.New(`can not switch to "` + algName +
+				`" algorithm while per-object random readers are in use`)
+		}
+		return nil
+	}
+	cAlgName := C.CString(algName)
+	defer C.free(unsafe.Pointer(cAlgName))
+	if C.oqsgo_OQS_randombytes_switch_algorithm(cAlgName) != C.OQS_SUCCESS {
+		return errors.New("can not switch to \"" + algName + "\" algorithm")
+	}
+	return nil
+}
+
+func randomBytesCustomAlgorithm() {
+	C.oqsgo_OQS_randombytes_custom_algorithm(
+		(C.rand_algorithm_ptr)(unsafe.Pointer(C.randAlgorithmPtr_cgo)))
+}
+
+/**************** END liboqs Randomness ****************/
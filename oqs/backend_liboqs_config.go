@@ -0,0 +1,62 @@
+//go:build !oqs_purego
+
+package oqs
+
+/*
+#include <oqs/oqs.h>
+static const char *oqsgo_header_version(void) {
+	return OQS_VERSION_TEXT;
+}
+static const char *oqsgo_header_build_target(void) {
+#ifdef OQS_COMPILE_BUILD_TARGET
+	return OQS_COMPILE_BUILD_TARGET;
+#else
+	return "";
+#endif
+}
+static const char *oqsgo_header_build_flags(void) {
+	return ""
+#ifdef OQS_DIST_BUILD
+		" OQS_DIST_BUILD"
+#endif
+#ifdef OQS_USE_OPENSSL
+		" OQS_USE_OPENSSL"
+#endif
+#ifdef OQS_USE_AES_OPENSSL
+		" OQS_USE_AES_OPENSSL"
+#endif
+#ifdef OQS_USE_SHA2_OPENSSL
+		" OQS_USE_SHA2_OPENSSL"
+#endif
+#ifdef OQS_USE_SHA3_OPENSSL
+		" OQS_USE_SHA3_OPENSSL"
+#endif
+#ifdef OQS_OPT_TARGET
+		" OQS_OPT_TARGET=" OQS_OPT_TARGET
+#endif
+		;
+}
+*/
+import "C"
+
+import "strings"
+
+/**************** liboqs build configuration ****************/
+
+// headerVersion returns OQS_VERSION_TEXT of the liboqs headers the package was
+// compiled against.
+func headerVersion() string {
+	return C.GoString(C.oqsgo_header_version())
+}
+
+// headerBuildTarget returns the liboqs build target recorded in oqsconfig.h.
+func headerBuildTarget() string {
+	return C.GoString(C.oqsgo_header_build_target())
+}
+
+// headerBuildFlags returns the liboqs build options recorded in oqsconfig.h.
+func headerBuildFlags() []string {
+	return strings.Fields(C.GoString(C.oqsgo_header_build_flags()))
+}
+
+/**************** END liboqs build configuration ****************/
@@ -0,0 +1,99 @@
+//go:build !oqs_purego
+
+package oqs
+
+/*
+#include "oqsgo.h"
+static const char *oqsgo_compiled_variants(void) {
+	return ""
+#ifdef OQS_ENABLE_KEM_ml_kem_768_x86_64
+		" ML-KEM:x86_64"
+#endif
+#ifdef OQS_ENABLE_KEM_ml_kem_768_aarch64
+		" ML-KEM:aarch64"
+#endif
+#ifdef OQS_ENABLE_SIG_ml_dsa_65_avx2
+		" ML-DSA:avx2"
+#endif
+#ifdef OQS_ENABLE_SIG_falcon_512_avx2
+		" Falcon:avx2"
+#endif
+#ifdef OQS_ENABLE_SIG_falcon_512_aarch64
+		" Falcon:aarch64"
+#endif
+#ifdef OQS_ENABLE_SIG_sphincs_sha2_128f_simple_avx2
+		" SPHINCS+:avx2"
+#endif
+#ifdef OQS_ENABLE_KEM_classic_mceliece_348864_avx2
+		" Classic-McEliece:avx2"
+#endif
+#ifdef OQS_ENABLE_SIG_mayo_1_avx2
+		" MAYO:avx2"
+#endif
+		;
+}
+static int oqsgo_dist_build(void) {
+#ifdef OQS_DIST_BUILD
+	return 1;
+#else
+	return 0;
+#endif
+}
+*/
+import "C"
+
+import "strings"
+
+/**************** liboqs CPU features ****************/
+
+// cpuExtensions maps the names reported by CPUFeatures to the liboqs CPU
+// extensions.
+var cpuExtensions = map[string]C.OQS_CPU_EXT{
+	"adx":        C.OQS_CPU_EXT_ADX,
+	"aes":        C.OQS_CPU_EXT_AES,
+	"avx":        C.OQS_CPU_EXT_AVX,
+	"avx2":       C.OQS_CPU_EXT_AVX2,
+	"avx512":     C.OQS_CPU_EXT_AVX512,
+	"bmi1":       C.OQS_CPU_EXT_BMI1,
+	"bmi2":       C.OQS_CPU_EXT_BMI2,
+	"pclmulqdq":  C.OQS_CPU_EXT_PCLMULQDQ,
+	"vpclmulqdq": C.OQS_CPU_EXT_VPCLMULQDQ,
+	"popcnt":     C.OQS_CPU_EXT_POPCNT,
+	"sse":        C.OQS_CPU_EXT_SSE,
+	"sse2":       C.OQS_CPU_EXT_SSE2,
+	"sse3":       C.OQS_CPU_EXT_SSE3,
+	"arm_aes":    C.OQS_CPU_EXT_ARM_AES,
+	"arm_sha2":   C.OQS_CPU_EXT_ARM_SHA2,
+	"arm_sha3":   C.OQS_CPU_EXT_ARM_SHA3,
+	"arm_neon":   C.OQS_CPU_EXT_ARM_NEON,
+}
+
+// cpuFeatures returns the CPU extensions detected by OQS_CPU_has_extension().
+func cpuFeatures() map[string]bool {
+	features := make(map[string]bool, len(cpuExtensions))
+	for name, ext := range cpuExtensions {
+		features[name] = C.oqsgo_OQS_CPU_has_extension(ext) != 0
+	}
+	return features
+}
+
+// compiledVariants returns the optimized variants liboqs was built with, keyed
+// by algorithm family, as recorded in oqsconfig.h for a representative
+// parameter set of each family.
+func compiledVariants() map[string]string {
+	variants := make(map[string]string)
+	for _, field := range strings.Fields(
+		C.GoString(C.oqsgo_compiled_variants())) {
+		family, variant, _ := strings.Cut(field, ":")
+		variants[family] = variant
+	}
+	return variants
+}
+
+// runtimeDispatch reports whether liboqs selects the optimized variants at
+// runtime from the CPU features, i.e., whether it is a distribution build.
+func runtimeDispatch() bool {
+	return C.oqsgo_dist_build() != 0
+}
+
+/**************** END liboqs CPU features ****************/
@@ -0,0 +1,304 @@
+//go:build oqs_dlopen && !oqs_purego
+
+// Definitions of the oqsgo_ functions declared in oqsgo.h, which forward to
+// the liboqs loaded at runtime by oqsgo_load_library, see
+// backend_liboqs_dlopen.go. Until a library is loaded, they fail, or fall back
+// to the operating system for oqsgo_OQS_randombytes and oqsgo_OQS_MEM_cleanse.
+
+#include <dlfcn.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <strings.h>
+#include <unistd.h>
+#if defined(__APPLE__)
+#include <sys/random.h>
+#endif
+#include "oqsgo.h"
+
+// The resolved symbols, NULL until a library is loaded, and for the missing
+// optional ones.
+#define OQSGO_POINTER(name, required) static __typeof__(name) *dl_##name;
+OQSGO_SYMBOLS(OQSGO_POINTER)
+
+static const struct {
+	const char *name;
+	void **ptr;
+	int required;
+} oqsgo_symbols[] = {
+#define OQSGO_ENTRY(name, required) {#name, (void **)&dl_##name, required},
+	OQSGO_SYMBOLS(OQSGO_ENTRY)
+};
+
+#define OQSGO_SYMBOL_COUNT (sizeof(oqsgo_symbols) / sizeof(oqsgo_symbols[0]))
+
+// The custom RNG algorithm set before a library is loaded, which serves
+// oqsgo_OQS_randombytes until then, and is installed in the library once
+// loaded.
+static void (*oqsgo_pending_rand)(uint8_t *, size_t);
+
+// oqsgo_error formats an error message, to be freed by the caller.
+static char *oqsgo_error(const char *format, const char *arg) {
+	size_t len = strlen(format) + strlen(arg) + 1;
+	char *message = malloc(len);
+	if (message != NULL) {
+		snprintf(message, len, format, arg);
+	}
+	return message;
+}
+
+// oqsgo_load_library loads liboqs from path and resolves its symbols. It
+// returns NULL on success. Otherwise, the library is not loaded, *symbol is
+// set to the name of the missing required symbol, if any, and the returned
+// error message must be freed by the caller. The caller serializes the calls
+// with each other and with the RNG algorithm functions, and loads a library at
+// most once.
+char *oqsgo_load_library(const char *path, const char **symbol) {
+	void *resolved[OQSGO_SYMBOL_COUNT];
+	void *library = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (library == NULL) {
+		const char *reason = dlerror();
+		return oqsgo_error("%s", reason != NULL ? reason : "dlopen failed");
+	}
+	for (size_t i = 0; i < OQSGO_SYMBOL_COUNT; i++) {
+		resolved[i] = dlsym(library, oqsgo_symbols[i].name);
+		if (resolved[i] == NULL && oqsgo_symbols[i].required) {
+			*symbol = oqsgo_symbols[i].name;
+			dlclose(library);
+			return oqsgo_error("undefined symbol %s", oqsgo_symbols[i].name);
+		}
+	}
+	for (size_t i = 0; i < OQSGO_SYMBOL_COUNT; i++) {
+		*oqsgo_symbols[i].ptr = resolved[i];
+	}
+	if (oqsgo_pending_rand != NULL) {
+		dl_OQS_randombytes_custom_algorithm(oqsgo_pending_rand);
+		oqsgo_pending_rand = NULL;
+	}
+	return NULL;
+}
+
+void oqsgo_OQS_init(void) {
+	if (dl_OQS_init != NULL) {
+		dl_OQS_init();
+	}
+}
+
+void oqsgo_OQS_destroy(void) {
+	if (dl_OQS_destroy != NULL) {
+		dl_OQS_destroy();
+	}
+}
+
+void oqsgo_OQS_thread_stop(void) {
+	if (dl_OQS_thread_stop != NULL) {
+		dl_OQS_thread_stop();
+	}
+}
+
+const char *oqsgo_OQS_version(void) {
+	return dl_OQS_version != NULL ? dl_OQS_version() : NULL;
+}
+
+int oqsgo_OQS_CPU_has_extension(OQS_CPU_EXT ext) {
+	if (dl_OQS_CPU_has_extension == NULL) {
+		return 0;
+	}
+	return dl_OQS_CPU_has_extension(ext);
+}
+
+void oqsgo_OQS_MEM_cleanse(void *ptr, size_t len) {
+	if (dl_OQS_MEM_cleanse != NULL) {
+		dl_OQS_MEM_cleanse(ptr, len);
+		return;
+	}
+	volatile unsigned char *p = ptr;
+	while (len--) {
+		*p++ = 0;
+	}
+}
+
+void oqsgo_OQS_randombytes(uint8_t *random_array, size_t bytes_to_read) {
+	if (dl_OQS_randombytes != NULL) {
+		dl_OQS_randombytes(random_array, bytes_to_read);
+		return;
+	}
+	if (oqsgo_pending_rand != NULL) {
+		oqsgo_pending_rand(random_array, bytes_to_read);
+		return;
+	}
+	// getentropy reads at most 256 bytes at once, and liboqs aborts as well
+	// when the system RNG fails
+	while (bytes_to_read > 0) {
+		size_t n = bytes_to_read < 256 ? bytes_to_read : 256;
+		if (getentropy(random_array, n) != 0) {
+			abort();
+		}
+		random_array += n;
+		bytes_to_read -= n;
+	}
+}
+
+OQS_STATUS oqsgo_OQS_randombytes_switch_algorithm(const char *algorithm) {
+	if (dl_OQS_randombytes_switch_algorithm != NULL) {
+		return dl_OQS_randombytes_switch_algorithm(algorithm);
+	}
+	// Only the system RNG is available until a library is loaded
+	if (strcasecmp(algorithm, "system") != 0) {
+		return OQS_ERROR;
+	}
+	oqsgo_pending_rand = NULL;
+	return OQS_SUCCESS;
+}
+
+void oqsgo_OQS_randombytes_custom_algorithm(
+    void (*algorithm_ptr)(uint8_t *, size_t)) {
+	if (dl_OQS_randombytes_custom_algorithm != NULL) {
+		dl_OQS_randombytes_custom_algorithm(algorithm_ptr);
+		return;
+	}
+	oqsgo_pending_rand = algorithm_ptr;
+}
+
+int oqsgo_OQS_KEM_alg_count(void) {
+	return dl_OQS_KEM_alg_count != NULL ? dl_OQS_KEM_alg_count() : 0;
+}
+
+const char *oqsgo_OQS_KEM_alg_identifier(size_t i) {
+	if (dl_OQS_KEM_alg_identifier == NULL) {
+		return NULL;
+	}
+	return dl_OQS_KEM_alg_identifier(i);
+}
+
+int oqsgo_OQS_KEM_alg_is_enabled(const char *method_name) {
+	if (dl_OQS_KEM_alg_is_enabled == NULL) {
+		return 0;
+	}
+	return dl_OQS_KEM_alg_is_enabled(method_name);
+}
+
+OQS_KEM *oqsgo_OQS_KEM_new(const char *method_name) {
+	return dl_OQS_KEM_new != NULL ? dl_OQS_KEM_new(method_name) : NULL;
+}
+
+OQS_STATUS oqsgo_OQS_KEM_keypair(const OQS_KEM *kem, uint8_t *public_key,
+                                 uint8_t *secret_key) {
+	if (dl_OQS_KEM_keypair == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_KEM_keypair(kem, public_key, secret_key);
+}
+
+OQS_STATUS oqsgo_OQS_KEM_encaps(const OQS_KEM *kem, uint8_t *ciphertext,
+                                uint8_t *shared_secret,
+                                const uint8_t *public_key) {
+	if (dl_OQS_KEM_encaps == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_KEM_encaps(kem, ciphertext, shared_secret, public_key);
+}
+
+OQS_STATUS oqsgo_OQS_KEM_decaps(const OQS_KEM *kem, uint8_t *shared_secret,
+                                const uint8_t *ciphertext,
+                                const uint8_t *secret_key) {
+	if (dl_OQS_KEM_decaps == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_KEM_decaps(kem, shared_secret, ciphertext, secret_key);
+}
+
+void oqsgo_OQS_KEM_free(OQS_KEM *kem) {
+	if (dl_OQS_KEM_free != NULL) {
+		dl_OQS_KEM_free(kem);
+	}
+}
+
+int oqsgo_OQS_SIG_alg_count(void) {
+	return dl_OQS_SIG_alg_count != NULL ? dl_OQS_SIG_alg_count() : 0;
+}
+
+const char *oqsgo_OQS_SIG_alg_identifier(size_t i) {
+	if (dl_OQS_SIG_alg_identifier == NULL) {
+		return NULL;
+	}
+	return dl_OQS_SIG_alg_identifier(i);
+}
+
+int oqsgo_OQS_SIG_alg_is_enabled(const char *method_name) {
+	if (dl_OQS_SIG_alg_is_enabled == NULL) {
+		return 0;
+	}
+	return dl_OQS_SIG_alg_is_enabled(method_name);
+}
+
+OQS_SIG *oqsgo_OQS_SIG_new(const char *method_name) {
+	return dl_OQS_SIG_new != NULL ? dl_OQS_SIG_new(method_name) : NULL;
+}
+
+OQS_STATUS oqsgo_OQS_SIG_keypair(const OQS_SIG *sig, uint8_t *public_key,
+                                 uint8_t *secret_key) {
+	if (dl_OQS_SIG_keypair == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_SIG_keypair(sig, public_key, secret_key);
+}
+
+OQS_STATUS oqsgo_OQS_SIG_sign(const OQS_SIG *sig, uint8_t *signature,
+                              size_t *signature_len, const uint8_t *message,
+                              size_t message_len, const uint8_t *secret_key) {
+	if (dl_OQS_SIG_sign == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_SIG_sign(sig, signature, signature_len, message, message_len,
+	                       secret_key);
+}
+
+OQS_STATUS oqsgo_OQS_SIG_sign_with_ctx_str(const OQS_SIG *sig,
+                                           uint8_t *signature,
+                                           size_t *signature_len,
+                                           const uint8_t *message,
+                                           size_t message_len,
+                                           const uint8_t *ctx_str,
+                                           size_t ctx_str_len,
+                                           const uint8_t *secret_key) {
+	if (dl_OQS_SIG_sign_with_ctx_str == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_SIG_sign_with_ctx_str(sig, signature, signature_len, message,
+	                                    message_len, ctx_str, ctx_str_len,
+	                                    secret_key);
+}
+
+OQS_STATUS oqsgo_OQS_SIG_verify(const OQS_SIG *sig, const uint8_t *message,
+                                size_t message_len, const uint8_t *signature,
+                                size_t signature_len,
+                                const uint8_t *public_key) {
+	if (dl_OQS_SIG_verify == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_SIG_verify(sig, message, message_len, signature,
+	                         signature_len, public_key);
+}
+
+OQS_STATUS oqsgo_OQS_SIG_verify_with_ctx_str(const OQS_SIG *sig,
+                                             const uint8_t *message,
+                                             size_t message_len,
+                                             const uint8_t *signature,
+                                             size_t signature_len,
+                                             const uint8_t *ctx_str,
+                                             size_t ctx_str_len,
+                                             const uint8_t *public_key) {
+	if (dl_OQS_SIG_verify_with_ctx_str == NULL) {
+		return OQS_ERROR;
+	}
+	return dl_OQS_SIG_verify_with_ctx_str(sig, message, message_len, signature,
+	                                      signature_len, ctx_str, ctx_str_len,
+	                                      public_key);
+}
+
+void oqsgo_OQS_SIG_free(OQS_SIG *sig) {
+	if (dl_OQS_SIG_free != NULL) {
+		dl_OQS_SIG_free(sig);
+	}
+}
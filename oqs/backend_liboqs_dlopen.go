@@ -0,0 +1,113 @@
+//go:build oqs_dlopen && !oqs_purego
+
+package oqs
+
+/*
+#cgo CFLAGS: -DOQSGO_DLOPEN
+#cgo linux LDFLAGS: -ldl
+#include <stdlib.h>
+char *oqsgo_load_library(const char *path, const char **symbol);
+*/
+import "C"
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+/**************** liboqs dynamic loading ****************/
+
+// With the oqs_dlopen build tag, the liboqs backend is not linked against
+// liboqs. The liboqs functions it calls, under the oqsgo_ prefix of oqsgo.h,
+// are defined in backend_liboqs_dlopen.c, and forward to the library loaded at
+// runtime with dlopen from the path in LibraryEnv, or from the default library
+// name, or with LoadLibrary. The liboqs headers are still needed at build time, e.g.
+// with CGO_CFLAGS=-I/usr/local/include, and the loaded library must match
+// their ABI.
+
+var (
+	// libraryMu serializes the loading of liboqs.
+	libraryMu sync.Mutex
+	// loadedPath is the path liboqs was loaded from, or "" if it is not
+	// loaded. It is guarded by libraryMu.
+	loadedPath string
+	// loadErr is the error of the last failed load, reported by Init while
+	// liboqs is not loaded. It is guarded by libraryMu.
+	loadErr error = loadDefaultLibrary()
+)
+
+// defaultLibraryName returns the name liboqs is loaded from when LibraryEnv is
+// not set, which the dynamic linker looks up in its search path.
+func defaultLibraryName() string {
+	if runtime.GOOS == "darwin" {
+		return "liboqs.dylib"
+	}
+	return "liboqs.so"
+}
+
+// loadDefaultLibrary loads liboqs from the path in LibraryEnv, or from the
+// default library name. It runs before the init functions of the package.
+func loadDefaultLibrary() error {
+	path := os.Getenv(LibraryEnv)
+	if path == "" {
+		path = defaultLibraryName()
+	}
+	return dlopenLibrary(path)
+}
+
+// dlopenLibrary loads liboqs from path, with libraryMu and randMu held or
+// during package initialization. The custom RNG algorithm, or the dispatch of
+// the per-object readers, installed before is carried over to the library.
+func dlopenLibrary(path string) error {
+	cPath := C.CString(path)
+	defer C.free(unsafe.Pointer(cPath))
+	var symbol *C.char
+	if message := C.oqsgo_load_library(cPath, &symbol); message != nil {
+		defer C.free(unsafe.Pointer(message))
+		return &LibraryError{Path: path, Symbol: C.GoString(symbol),
+			Reason: C.GoString(message)}
+	}
+	loadedPath = path
+	return nil
+}
+
+// loadLibrary loads liboqs from path, unless it is already loaded.
+func loadLibrary(path string) error {
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+	if loadedPath != "" {
+		if loadedPath == path {
+			return nil
+		}
+		return &LibraryError{Path: path,
+			Reason: "liboqs is already loaded from " + loadedPath}
+	}
+	randMu.Lock()
+	err := dlopenLibrary(path)
+	randMu.Unlock()
+	if err != nil {
+		loadErr = err
+		return err
+	}
+	loadErr = nil
+	initializeLoaded()
+	return nil
+}
+
+// libraryPath returns the path liboqs was loaded from, or "".
+func libraryPath() string {
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+	return loadedPath
+}
+
+// libraryError returns the reason why liboqs is not loaded, or nil.
+func libraryError() error {
+	libraryMu.Lock()
+	defer libraryMu.Unlock()
+	return loadErr
+}
+
+/**************** END liboqs dynamic loading ****************/
@@ -0,0 +1,32 @@
+//go:build !oqs_purego && !oqs_dlopen
+
+package oqs
+
+/*
+#cgo pkg-config: liboqs-go
+*/
+import "C"
+
+/**************** liboqs linking ****************/
+
+// By default, the liboqs backend is linked against liboqs, as configured by
+// the liboqs-go pkg-config file, see backend_liboqs_dlopen.go for the
+// alternative.
+
+// loadLibrary fails, as liboqs is linked at build time.
+func loadLibrary(path string) error {
+	return &LibraryError{Path: path, Reason: "liboqs is linked at build " +
+		"time, loading it at runtime requires the oqs_dlopen build tag"}
+}
+
+// libraryPath returns "", as liboqs is linked at build time.
+func libraryPath() string {
+	return ""
+}
+
+// libraryError returns nil, as liboqs is linked at build time.
+func libraryError() error {
+	return nil
+}
+
+/**************** END liboqs linking ****************/
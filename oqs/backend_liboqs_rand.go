@@ -0,0 +1,187 @@
+//go:build !oqs_purego
+
+package oqs
+
+/*
+#include <stdint.h>
+static _Thread_local uintptr_t rand_handle;
+uintptr_t oqsgo_get_rand_handle(void) { return rand_handle; }
+void oqsgo_set_rand_handle(uintptr_t handle) { rand_handle = handle; }
+*/
+import "C"
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"runtime/cgo"
+	"strings"
+	"sync"
+)
+
+/**************** liboqs per-object randomness ****************/
+
+// liboqs has a single, process-wide RNG algorithm. Per-object readers set with
+// WithRandomReader are supported by installing randAlgorithmPtr as the liboqs
+// RNG algorithm while any of them is alive, and by dispatching on a
+// thread-local handle that is set for the duration of each liboqs call made on
+// behalf of an object with a reader, or for the whole scope of
+// WithRandomSource. Threads without a handle are served by the custom RNG
+// algorithm, if any, or by the system RNG.
+
+// randSources counts the live sources of randomness, i.e. the per-object
+// readers and the WithRandomSource scopes. randAlgorithmPtr dispatches while
+// it is not zero, and the RNG algorithm selected by the user is reinstated when
+// it drops back to zero. It is guarded by randMu.
+var randSources int
+
+// enableRandomDispatch installs randAlgorithmPtr as the liboqs RNG algorithm,
+// unless already done, and accounts for a new source of randomness, used by
+// feature.
+func enableRandomDispatch(feature string) error {
+	randMu.Lock()
+	defer randMu.Unlock()
+	if randSources == 0 {
+		switch algName := CurrentRandomAlgorithm(); {
+		case algName == CustomRandomAlgorithm:
+			// randAlgorithmPtr is already installed
+		case strings.EqualFold(algName, "system"):
+			randomBytesCustomAlgorithm()
+		default:
+			return &randomDispatchError{feature: feature, algName: algName}
+		}
+	}
+	randSources++
+	return nil
+}
+
+// disableRandomDispatch accounts for a released source of randomness, and
+// switches liboqs back to its system RNG once the last one is released, unless
+// a custom RNG algorithm is in use.
+func disableRandomDispatch() {
+	randMu.Lock()
+	defer randMu.Unlock()
+	randSources--
+	if randSources == 0 && CurrentRandomAlgorithm() != CustomRandomAlgorithm {
+		// Switching back can not fail, as "system" was in use before
+		_ = randomBytesSwitchAlgorithm("system")
+	}
+}
+
+// randSource is a per-object source of randomness. Copies of an object and
+// abandoned context operations may use it concurrently, hence the reads from r
+// are serialized by mu.
+type randSource struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+// randCall is the state of a liboqs call, or of a WithRandomSource scope,
+// reading from a source of randomness. The thread-local handle refers to it,
+// so that the read error of a call is neither seen nor reset by the concurrent
+// calls reading from the same source.
+type randCall struct {
+	source *randSource
+	err    error
+}
+
+// zeroReader is an endless source of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	clear(b)
+	return len(b), nil
+}
+
+// newRandSource returns the source of randomness reading from r for feature,
+// or nil if r is nil.
+func newRandSource(r io.Reader, feature string) (*randSource, error) {
+	if r == nil {
+		return nil, nil
+	}
+	if err := enableRandomDispatch(feature); err != nil {
+		return nil, err
+	}
+	return &randSource{r: r}, nil
+}
+
+// currentRandCall returns the call reading the randomness of the calling
+// thread, or nil if there is none.
+func currentRandCall() *randCall {
+	handle := C.oqsgo_get_rand_handle()
+	if handle == 0 {
+		return nil
+	}
+	return cgo.Handle(handle).Value().(*randCall)
+}
+
+// read fills randomArray from the source of c. After a read error, randomArray
+// is zeroed, the following reads of c are skipped, and the error is reported
+// by do or randomScope.
+func (c *randCall) read(randomArray []byte) {
+	if c.err == nil {
+		c.source.mu.Lock()
+		_, c.err = io.ReadFull(c.source.r, randomArray)
+		c.source.mu.Unlock()
+	}
+	if c.err != nil {
+		clear(randomArray)
+	}
+}
+
+// readError returns the read error of c, if any.
+func (c *randCall) readError() error {
+	if c == nil || c.err == nil {
+		return nil
+	}
+	return errors.New("can not read from the random source: " + c.err.Error())
+}
+
+// run runs fn with the randomness of the calling thread read by c, and returns
+// the read error of c, if any.
+func (c *randCall) run(fn func()) error {
+	handle := cgo.NewHandle(c)
+	defer handle.Delete()
+	// The handle is thread-local, so the goroutine must not migrate
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	prev := C.oqsgo_get_rand_handle()
+	C.oqsgo_set_rand_handle(C.uintptr_t(handle))
+	defer C.oqsgo_set_rand_handle(prev)
+	fn()
+	return c.readError()
+}
+
+// do runs fn, which calls into liboqs, with the randomness of the calling
+// thread read from s. A nil s runs fn with the randomness of the enclosing
+// WithRandomSource, if any, or with the process-wide RNG algorithm.
+func (s *randSource) do(fn func()) error {
+	if s == nil {
+		fn()
+		// A thread with a WithRandomSource handle is locked to its goroutine,
+		// hence the handle can not have changed
+		return currentRandCall().readError()
+	}
+	return (&randCall{source: s}).run(fn)
+}
+
+// randomScope runs fn with the randomness of the calling thread read from r.
+// The goroutine is locked to its thread for the duration of fn, so that the
+// handle follows it. A read error is latched until fn returns.
+func randomScope(r io.Reader, fn func()) error {
+	s, err := newRandSource(r, "WithRandomSource")
+	if err != nil {
+		return err
+	}
+	defer s.free()
+	return (&randCall{source: s}).run(fn)
+}
+
+// free releases s.
+func (s *randSource) free() {
+	if s != nil {
+		disableRandomDispatch()
+	}
+}
+
+/**************** END liboqs per-object randomness ****************/
@@ -0,0 +1,59 @@
+//go:build !cgo && !oqs_purego
+
+package oqs
+
+import (
+	"errors"
+	"io"
+)
+
+/**************** No-cgo backend ****************/
+
+// Without cgo, and without the oqs_purego build tag, the package builds with a
+// backend that enables no algorithm, so that the code depending on the
+// interfaces of the package, e.g. through the fakes of the oqstest package,
+// can be built and tested without liboqs. Init fails for every algorithm, and
+// the randomness is served from crypto/rand or from the custom RNG algorithm.
+
+// errNoCgo is returned by Init with the no-cgo backend.
+var errNoCgo = errors.New("the oqs package was built without cgo, build it " +
+	"with cgo and liboqs, or with the oqs_purego tag")
+
+// backendName returns the name of the no-cgo backend.
+func backendName() string {
+	return "nocgo"
+}
+
+func kemAlgCount() int {
+	return 0
+}
+
+func kemAlgIdentifier(algID int) string {
+	return ""
+}
+
+func kemAlgIsEnabled(algName string) bool {
+	return false
+}
+
+func newKEMBackend(algName string, rand io.Reader) (kemBackend, error) {
+	return nil, errNoCgo
+}
+
+func sigAlgCount() int {
+	return 0
+}
+
+func sigAlgIdentifier(algID int) string {
+	return ""
+}
+
+func sigAlgIsEnabled(algName string) bool {
+	return false
+}
+
+func newSigBackend(algName string, rand io.Reader) (sigBackend, error) {
+	return nil, errNoCgo
+}
+
+/**************** END No-cgo backend ****************/
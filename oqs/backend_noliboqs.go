@@ -0,0 +1,177 @@
+//go:build oqs_purego || !cgo
+
+package oqs
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+/**************** Backends without liboqs ****************/
+
+// The pure-Go backend and the backend of the builds without cgo do not use
+// liboqs. They share the functions below, and serve the randomness from
+// crypto/rand or from the custom RNG algorithm.
+
+// backendVersion returns an empty string, as liboqs is not used.
+func backendVersion() string {
+	return ""
+}
+
+// initBackend does nothing, as the pure-Go backend has no global state.
+func initBackend() {}
+
+// destroyBackend does nothing, as the pure-Go backend has no global state.
+func destroyBackend() {}
+
+// threadStop does nothing, as the pure-Go backend has no per-thread state.
+func threadStop() {}
+
+// memCleanse zeroes v.
+func memCleanse(v []byte) {
+	clear(v)
+}
+
+// loadLibrary fails, as liboqs is not used.
+func loadLibrary(path string) error {
+	return &LibraryError{Path: path, Reason: "the " + backendName() +
+		" backend does not use liboqs"}
+}
+
+// libraryPath returns "", as liboqs is not used.
+func libraryPath() string {
+	return ""
+}
+
+// libraryError returns nil, as liboqs is not used.
+func libraryError() error {
+	return nil
+}
+
+// headerVersion returns an empty string, as liboqs is not used.
+func headerVersion() string {
+	return ""
+}
+
+// headerBuildTarget returns an empty string, as liboqs is not used.
+func headerBuildTarget() string {
+	return ""
+}
+
+// headerBuildFlags returns nil, as liboqs is not used.
+func headerBuildFlags() []string {
+	return nil
+}
+
+// cpuFeatures returns nil, as liboqs is not used.
+func cpuFeatures() map[string]bool {
+	return nil
+}
+
+// compiledVariants returns nil, as liboqs is not used.
+func compiledVariants() map[string]string {
+	return nil
+}
+
+// runtimeDispatch returns false, as liboqs is not used.
+func runtimeDispatch() bool {
+	return false
+}
+
+/**************** END Backends without liboqs ****************/
+
+/**************** Go Randomness ****************/
+
+// Without liboqs, there is no thread-local storage to dispatch on, hence the
+// WithRandomSource scopes are serialized by randScopeMu, and the reader of the
+// active scope serves the randomness of the whole process while its fn runs.
+// The RNG algorithm in use is left untouched, and serves again once the scope
+// ends.
+
+// randScope is the state of a WithRandomSource call. Its reads are serialized,
+// as the goroutines started by fn may read concurrently.
+type randScope struct {
+	mu  sync.Mutex
+	r   io.Reader
+	err error
+}
+
+var (
+	// randScopeMu is held for the whole duration of a WithRandomSource scope.
+	randScopeMu sync.Mutex
+	// activeRandScope is the scope in progress, if any.
+	activeRandScope atomic.Pointer[randScope]
+)
+
+func randomScope(r io.Reader, fn func()) error {
+	randScopeMu.Lock()
+	defer randScopeMu.Unlock()
+	scope := &randScope{r: r}
+	activeRandScope.Store(scope)
+	defer activeRandScope.Store(nil)
+	fn()
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.err != nil {
+		return errors.New("can not read from the random source: " +
+			scope.err.Error())
+	}
+	return nil
+}
+
+// read fills randomArray from the reader of s. After a read error, randomArray
+// is zeroed, and the following reads are skipped.
+func (s *randScope) read(randomArray []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		_, s.err = io.ReadFull(s.r, randomArray)
+	}
+	if s.err != nil {
+		clear(randomArray)
+		return errors.New("can not read from the random source: " +
+			s.err.Error())
+	}
+	return nil
+}
+
+func randomBytes(randomArray []byte) error {
+	if scope := activeRandScope.Load(); scope != nil {
+		return scope.read(randomArray)
+	}
+	if callback := loadRandomCallback(); callback != nil {
+		callback(randomArray, len(randomArray))
+		healthTest(randomArray)
+		return nil
+	}
+	_, _ = rand.Read(randomArray)
+	return nil
+}
+
+// readRandom fills randomArray from the per-object reader r, or from the RNG
+// algorithm in use if r is nil.
+func readRandom(r io.Reader, randomArray []byte) error {
+	if r == nil {
+		return randomBytes(randomArray)
+	}
+	if _, err := io.ReadFull(r, randomArray); err != nil {
+		clear(randomArray)
+		return errors.New("can not read from the random source: " +
+			err.Error())
+	}
+	return nil
+}
+
+func randomBytesSwitchAlgorithm(algName string) error {
+	if algName != "system" {
+		return errors.New("can not switch to \"" + algName + "\" algorithm")
+	}
+	return nil
+}
+
+func randomBytesCustomAlgorithm() {}
+
+/**************** END Go Randomness ****************/
@@ -0,0 +1,328 @@
+//go:build oqs_purego && go1.27
+
+package oqs
+
+import (
+	"crypto/mldsa"
+	"crypto/mlkem"
+	"errors"
+	"io"
+)
+
+/**************** Pure-Go backend ****************/
+
+// The pure-Go backend implements ML-KEM-768, ML-KEM-1024, ML-DSA-44, ML-DSA-65
+// and ML-DSA-87 with the crypto/mlkem and crypto/mldsa packages of the Go
+// standard library. ML-KEM-512 is listed as supported but not enabled, since
+// the standard library does not implement it.
+//
+// Public keys, ciphertexts and signatures are byte-for-byte compatible with
+// liboqs. Secret keys, however, are stored in the FIPS 203/204 seed format
+// (64 bytes for ML-KEM, 32 bytes for ML-DSA) rather than in the expanded
+// format used by liboqs, hence KeyEncapsulationDetails.LengthSecretKey and
+// SignatureDetails.LengthSecretKey differ from the liboqs backend, and secret
+// keys can not be exchanged between the two backends.
+
+// mlkemSeedSize and mldsaSeedSize are the lengths of the ML-KEM and ML-DSA
+// secret key seeds.
+const (
+	mlkemSeedSize = mlkem.SeedSize
+	mldsaSeedSize = mldsa.PrivateKeySize
+)
+
+// backendName returns the name of the pure-Go backend.
+func backendName() string {
+	return "purego"
+}
+
+/**************** END Pure-Go backend ****************/
+
+/**************** Pure-Go KEMs ****************/
+
+// pureKEMNames lists the supported KEM algorithms, in liboqs order.
+var pureKEMNames = []string{"ML-KEM-512", "ML-KEM-768", "ML-KEM-1024"}
+
+// pureKEM implements kemBackend for one ML-KEM parameter set. The per-object
+// reader is only used for key generation, since crypto/mlkem always draws the
+// randomness of encapsulation from crypto/rand.
+type pureKEM struct {
+	algDetails  KeyEncapsulationDetails
+	rand        io.Reader
+	publicKey   func(seed []byte) ([]byte, error)
+	encapsulate func(publicKey []byte) (ss, ct []byte, err error)
+	decapsulate func(seed, ciphertext []byte) ([]byte, error)
+}
+
+// pureKEMs maps the enabled KEM algorithms to their constructors.
+var pureKEMs = map[string]func() *pureKEM{
+	"ML-KEM-768": func() *pureKEM {
+		return &pureKEM{
+			algDetails: mlkemDetails("ML-KEM-768", 3,
+				mlkem.EncapsulationKeySize768, mlkem.CiphertextSize768),
+			publicKey: func(seed []byte) ([]byte, error) {
+				dk, err := mlkem.NewDecapsulationKey768(seed)
+				if err != nil {
+					return nil, err
+				}
+				return dk.EncapsulationKey().Bytes(), nil
+			},
+			encapsulate: func(publicKey []byte) ([]byte, []byte, error) {
+				ek, err := mlkem.NewEncapsulationKey768(publicKey)
+				if err != nil {
+					return nil, nil, err
+				}
+				ss, ct := ek.Encapsulate()
+				return ss, ct, nil
+			},
+			decapsulate: func(seed, ciphertext []byte) ([]byte, error) {
+				dk, err := mlkem.NewDecapsulationKey768(seed)
+				if err != nil {
+					return nil, err
+				}
+				return dk.Decapsulate(ciphertext)
+			},
+		}
+	},
+	"ML-KEM-1024": func() *pureKEM {
+		return &pureKEM{
+			algDetails: mlkemDetails("ML-KEM-1024", 5,
+				mlkem.EncapsulationKeySize1024, mlkem.CiphertextSize1024),
+			publicKey: func(seed []byte) ([]byte, error) {
+				dk, err := mlkem.NewDecapsulationKey1024(seed)
+				if err != nil {
+					return nil, err
+				}
+				return dk.EncapsulationKey().Bytes(), nil
+			},
+			encapsulate: func(publicKey []byte) ([]byte, []byte, error) {
+				ek, err := mlkem.NewEncapsulationKey1024(publicKey)
+				if err != nil {
+					return nil, nil, err
+				}
+				ss, ct := ek.Encapsulate()
+				return ss, ct, nil
+			},
+			decapsulate: func(seed, ciphertext []byte) ([]byte, error) {
+				dk, err := mlkem.NewDecapsulationKey1024(seed)
+				if err != nil {
+					return nil, err
+				}
+				return dk.Decapsulate(ciphertext)
+			},
+		}
+	},
+}
+
+// mlkemDetails returns the details of an ML-KEM parameter set.
+func mlkemDetails(algName string, nistLevel int, lenPublicKey int,
+	lenCiphertext int,
+) KeyEncapsulationDetails {
+	return KeyEncapsulationDetails{
+		Name:               algName,
+		Version:            "FIPS203",
+		ClaimedNISTLevel:   nistLevel,
+		IsINDCCA:           true,
+		LengthPublicKey:    lenPublicKey,
+		LengthSecretKey:    mlkemSeedSize,
+		LengthCiphertext:   lenCiphertext,
+		LengthSharedSecret: mlkem.SharedKeySize,
+	}
+}
+
+func kemAlgCount() int {
+	return len(pureKEMNames)
+}
+
+func kemAlgIdentifier(algID int) string {
+	return pureKEMNames[algID]
+}
+
+func kemAlgIsEnabled(algName string) bool {
+	_, ok := pureKEMs[algName]
+	return ok
+}
+
+func newKEMBackend(algName string, rand io.Reader) (kemBackend, error) {
+	newKEM, ok := pureKEMs[algName]
+	if !ok {
+		return nil, errors.New(`can not instantiate "` + algName + `" KEM`)
+	}
+	kem := newKEM()
+	if rand != nil {
+		// Copies of the object may read concurrently
+		kem.rand = &lockedReader{r: rand}
+	}
+	return kem, nil
+}
+
+func (b *pureKEM) details() KeyEncapsulationDetails {
+	return b.algDetails
+}
+
+func (b *pureKEM) keypair(publicKey, secretKey []byte) error {
+	if err := readRandom(b.rand, secretKey); err != nil {
+		return err
+	}
+	pk, err := b.publicKey(secretKey)
+	if err != nil {
+		return errors.New("can not generate keypair")
+	}
+	copy(publicKey, pk)
+	return nil
+}
+
+func (b *pureKEM) encaps(ciphertext, sharedSecret, publicKey []byte) error {
+	ss, ct, err := b.encapsulate(publicKey)
+	if err != nil {
+		return errors.New("can not encapsulate secret")
+	}
+	copy(ciphertext, ct)
+	copy(sharedSecret, ss)
+	return nil
+}
+
+func (b *pureKEM) decaps(sharedSecret, ciphertext, secretKey []byte) error {
+	ss, err := b.decapsulate(secretKey, ciphertext)
+	if err != nil {
+		return errors.New("can not decapsulate secret")
+	}
+	copy(sharedSecret, ss)
+	return nil
+}
+
+func (b *pureKEM) free() {}
+
+/**************** END Pure-Go KEMs ****************/
+
+/**************** Pure-Go Sigs ****************/
+
+// pureSigNames lists the supported signature algorithms, in liboqs order.
+var pureSigNames = []string{"ML-DSA-44", "ML-DSA-65", "ML-DSA-87"}
+
+// pureSigParams maps the enabled signature algorithms to their parameters and
+// claimed NIST security levels.
+var pureSigParams = map[string]struct {
+	params    func() mldsa.Parameters
+	nistLevel int
+}{
+	"ML-DSA-44": {mldsa.MLDSA44, 2},
+	"ML-DSA-65": {mldsa.MLDSA65, 3},
+	"ML-DSA-87": {mldsa.MLDSA87, 5},
+}
+
+// pureSig implements sigBackend for one ML-DSA parameter set. The per-object
+// reader is only used for key generation, since crypto/mldsa always hedges
+// signatures with its own randomness.
+type pureSig struct {
+	algDetails SignatureDetails
+	params     mldsa.Parameters
+	rand       io.Reader
+}
+
+func sigAlgCount() int {
+	return len(pureSigNames)
+}
+
+func sigAlgIdentifier(algID int) string {
+	return pureSigNames[algID]
+}
+
+func sigAlgIsEnabled(algName string) bool {
+	_, ok := pureSigParams[algName]
+	return ok
+}
+
+func newSigBackend(algName string, rand io.Reader) (sigBackend, error) {
+	p, ok := pureSigParams[algName]
+	if !ok {
+		return nil, errors.New(`can not instantiate "` + algName +
+			`" signature mechanism`)
+	}
+	if rand != nil {
+		// Copies of the object may read concurrently
+		rand = &lockedReader{r: rand}
+	}
+	params := p.params()
+	return &pureSig{
+		algDetails: SignatureDetails{
+			Name:               algName,
+			Version:            "FIPS204",
+			ClaimedNISTLevel:   p.nistLevel,
+			IsEUFCMA:           true,
+			SigWithCtxSupport:  true,
+			LengthPublicKey:    params.PublicKeySize(),
+			LengthSecretKey:    mldsaSeedSize,
+			MaxLengthSignature: params.SignatureSize(),
+		},
+		params: params,
+		rand:   rand,
+	}, nil
+}
+
+func (b *pureSig) details() SignatureDetails {
+	return b.algDetails
+}
+
+func (b *pureSig) keypair(publicKey, secretKey []byte) error {
+	if err := readRandom(b.rand, secretKey); err != nil {
+		return err
+	}
+	sk, err := mldsa.NewPrivateKey(b.params, secretKey)
+	if err != nil {
+		return errors.New("can not generate keypair")
+	}
+	copy(publicKey, sk.PublicKey().Bytes())
+	return nil
+}
+
+func (b *pureSig) sign(signature, message, secretKey []byte) (int, error) {
+	return b.signWithCtxStr(signature, message, nil, secretKey)
+}
+
+func (b *pureSig) signWithCtxStr(signature, message, context,
+	secretKey []byte,
+) (int, error) {
+	sk, err := mldsa.NewPrivateKey(b.params, secretKey)
+	if err != nil {
+		return 0, errors.New("can not sign message")
+	}
+	s, err := sk.Sign(nil, message, &mldsa.Options{Context: string(context)})
+	if err != nil {
+		return 0, errors.New("can not sign message")
+	}
+	return copy(signature, s), nil
+}
+
+func (b *pureSig) signDeterministic(signature, message, context,
+	secretKey []byte,
+) (int, error) {
+	sk, err := mldsa.NewPrivateKey(b.params, secretKey)
+	if err != nil {
+		return 0, errors.New("can not sign message")
+	}
+	s, err := sk.SignDeterministic(message,
+		&mldsa.Options{Context: string(context)})
+	if err != nil {
+		return 0, errors.New("can not sign message")
+	}
+	return copy(signature, s), nil
+}
+
+func (b *pureSig) verify(message, signature, publicKey []byte) bool {
+	return b.verifyWithCtxStr(message, signature, nil, publicKey)
+}
+
+func (b *pureSig) verifyWithCtxStr(message, signature, context,
+	publicKey []byte,
+) bool {
+	pk, err := mldsa.NewPublicKey(b.params, publicKey)
+	if err != nil {
+		return false
+	}
+	opts := &mldsa.Options{Context: string(context)}
+	return mldsa.Verify(pk, message, signature, opts) == nil
+}
+
+func (b *pureSig) free() {}
+
+/**************** END Pure-Go Sigs ****************/
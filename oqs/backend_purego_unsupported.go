@@ -0,0 +1,8 @@
+//go:build oqs_purego && !go1.27
+
+package oqs
+
+// The pure-Go backend relies on the crypto/mldsa package, which is available
+// starting with Go 1.27. Building with the oqs_purego tag and an older Go
+// toolchain fails on the undefined identifier below.
+var _ = oqs_purego_backend_requires_go1_27_or_later
@@ -0,0 +1,171 @@
+package oqs
+
+import (
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+/**************** Batch signing and verification ****************/
+
+// Signature.SignBatch and Signature.VerifyBatch fan a batch out across several
+// backend contexts, i.e., several liboqs OQS_SIG objects with the liboqs
+// backend, each used by its own goroutine. The contexts are created on first
+// use, reused by subsequent batches, and freed by Signature.Clean.
+
+// VerifyItem is a single signature verification of a batch, see
+// Signature.VerifyBatch. A non-empty Context is verified as with
+// Signature.VerifyWithCtxStr.
+type VerifyItem struct {
+	Message   []byte
+	Signature []byte
+	Context   []byte
+	PublicKey []byte
+}
+
+// WithBatchParallelism sets the number of backend contexts, hence of
+// goroutines, used by Signature.SignBatch and Signature.VerifyBatch. The
+// default, also used if n is not positive, is runtime.GOMAXPROCS(0) at the time
+// of the first batch.
+func WithBatchParallelism(n int) Option {
+	return func(o *options) {
+		o.parallelism = n
+	}
+}
+
+// lockedReader serializes the reads of a per-object reader shared by the batch
+// contexts, or by the copies of an object with the pure-Go backend.
+type lockedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+}
+
+func (lr *lockedReader) Read(b []byte) (int, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return lr.r.Read(b)
+}
+
+// SignBatch signs each message of messages, and returns the signatures and the
+// errors in the order of messages, i.e., signatures[i] is nil if and only if
+// errs[i] is non-nil.
+func (sig *Signature) SignBatch(messages [][]byte) (signatures [][]byte,
+	errs []error,
+) {
+	signatures = make([][]byte, len(messages))
+	errs = make([]error, len(messages))
+	sig.runBatch(len(messages), errs, func(worker *Signature, i int) {
+		signatures[i], errs[i] = worker.Sign(messages[i])
+	})
+
+	return signatures, errs
+}
+
+// VerifyBatch verifies each item of items, and returns the verification
+// results and the errors in the order of items. An item for which
+// Signature.Verify or Signature.VerifyWithCtxStr would return an error is
+// reported as invalid, together with that error.
+func (sig *Signature) VerifyBatch(items []VerifyItem) (valid []bool,
+	errs []error,
+) {
+	valid = make([]bool, len(items))
+	errs = make([]error, len(items))
+	sig.runBatch(len(items), errs, func(worker *Signature, i int) {
+		item := &items[i]
+		if len(item.Context) > 0 {
+			valid[i], errs[i] = worker.VerifyWithCtxStr(item.Message,
+				item.Signature, item.Context, item.PublicKey)
+		} else {
+			valid[i], errs[i] = worker.Verify(item.Message, item.Signature,
+				item.PublicKey)
+		}
+	})
+
+	return valid, errs
+}
+
+// runBatch calls op for each index in [0, n), distributing the indices over
+// the batch contexts of the sig receiver. If the contexts can not be created,
+// the error is reported for every index.
+func (sig *Signature) runBatch(n int, errs []error,
+	op func(worker *Signature, i int),
+) {
+	if n == 0 {
+		return
+	}
+	backends, err := sig.batchBackends()
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return
+	}
+	defer sig.handle.release()
+	if len(backends) > n {
+		backends = backends[:n]
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for _, backend := range backends {
+		worker := &Signature{
+			handle:     newHandle(backend), // borrowed, never closed
+			secretKey:  sig.secretKey,
+			algDetails: sig.algDetails,
+			opts:       sig.opts,
+			publicKey:  sig.publicKey,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1)) - 1
+				if i >= n {
+					return
+				}
+				op(worker, i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// batchBackends acquires the handle of the sig receiver and returns its batch
+// contexts, creating them if needed. All contexts share the per-object reader,
+// if any. The handle must be released once the contexts are no longer used.
+func (sig *Signature) batchBackends() ([]sigBackend, error) {
+	if _, err := sig.handle.acquire(); err != nil {
+		return nil, err
+	}
+	backends, err := sig.handle.batchBackends(func() ([]sigBackend, error) {
+		n := sig.opts.parallelism
+		if n < 1 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		var rand io.Reader
+		if sig.opts.rand != nil {
+			rand = &lockedReader{r: sig.opts.rand}
+		}
+		backends := make([]sigBackend, 0, n)
+		for i := 0; i < n; i++ {
+			backend, err := newSigBackend(sig.algDetails.Name, rand)
+			if err != nil {
+				for _, b := range backends {
+					b.free()
+				}
+				return nil, err
+			}
+			backends = append(backends, backend)
+		}
+		return backends, nil
+	})
+	if err != nil {
+		sig.handle.release()
+		return nil, err
+	}
+
+	return backends, nil
+}
+
+/**************** END Batch signing and verification ****************/
@@ -1,3 +1,5 @@
+//go:build !oqs_purego
+
 package oqs
 
 // C callbacks, DO NOT CHANGE
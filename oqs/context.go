@@ -0,0 +1,338 @@
+package oqs
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+/**************** Worker pool ****************/
+
+// The context-aware variants of the KeyEncapsulation and Signature methods run
+// the underlying operation on a bounded pool of worker goroutines, and return
+// ctx.Err() as soon as ctx is done. An abandoned operation is not interrupted,
+// since liboqs calls can not be cancelled; it runs to completion on a snapshot
+// of the receiver, its results are discarded, and secret results are zeroed.
+// Clean waits for the abandoned operations of its receiver to finish.
+
+// workerPool is a counting semaphore bounding the number of operations run
+// concurrently by the context-aware methods.
+var (
+	workerPoolMu sync.Mutex
+	workerPool   = make(chan struct{}, runtime.GOMAXPROCS(0))
+)
+
+// SetWorkerPoolSize sets the maximum number of operations run concurrently by
+// the context-aware methods, such as KeyEncapsulation.GenerateKeyPairContext.
+// The default is runtime.GOMAXPROCS(0) at program start. Operations already
+// running are not affected.
+func SetWorkerPoolSize(size int) error {
+	if size < 1 {
+		return errors.New("the worker pool size must be positive")
+	}
+	workerPoolMu.Lock()
+	workerPool = make(chan struct{}, size)
+	workerPoolMu.Unlock()
+	return nil
+}
+
+// WorkerPoolSize returns the maximum number of operations run concurrently by
+// the context-aware methods.
+func WorkerPoolSize() int {
+	return cap(currentWorkerPool())
+}
+
+// currentWorkerPool returns the worker pool semaphore.
+func currentWorkerPool() chan struct{} {
+	workerPoolMu.Lock()
+	defer workerPoolMu.Unlock()
+	return workerPool
+}
+
+// runContext runs op on the worker pool and waits for it, or for ctx to be
+// done. In the latter case, discard, if non-nil, is called once op finished
+// successfully. pending tracks the operation until op finished.
+func runContext(ctx context.Context, pending *pendingGroup,
+	op func() error, discard func(),
+) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pool := currentWorkerPool()
+	select {
+	case pool <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	var mu sync.Mutex
+	abandoned := false
+	done := make(chan error, 1)
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		err := op()
+		<-pool
+		mu.Lock()
+		defer mu.Unlock()
+		if !abandoned {
+			done <- err
+		} else if err == nil && discard != nil {
+			discard()
+		}
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		mu.Lock()
+		defer mu.Unlock()
+		// The operation may have finished in the meantime
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		abandoned = true
+		return ctx.Err()
+	}
+}
+
+/**************** END Worker pool ****************/
+
+/**************** KeyEncapsulation context variants ****************/
+
+// pendingOps returns the operations run by the worker pool on the handle of
+// the kem receiver, which its copies share. An uninitialized receiver has no
+// handle, and its operations fail without using any backend.
+func (kem *KeyEncapsulation) pendingOps() *pendingGroup {
+	if kem.handle == nil {
+		return &pendingGroup{}
+	}
+	return &kem.handle.pending
+}
+
+// waitPending waits for the abandoned operations of the kem receiver and of
+// its copies.
+func (kem *KeyEncapsulation) waitPending() {
+	if kem.handle != nil {
+		kem.handle.pending.Wait()
+	}
+}
+
+// snapshot returns a copy of the kem receiver sharing its handle, on which the
+// context variants run.
+func (kem *KeyEncapsulation) snapshot() *KeyEncapsulation {
+	return &KeyEncapsulation{
+		handle:     kem.handle,
+		secretKey:  kem.secretKey,
+		algDetails: kem.algDetails,
+		opts:       kem.opts,
+		publicKey:  kem.publicKey,
+	}
+}
+
+// GenerateKeyPairContext works like KeyEncapsulation.GenerateKeyPair, but runs
+// on the worker pool and returns ctx.Err() once ctx is done. The secret key of
+// the kem receiver is only replaced on success.
+func (kem *KeyEncapsulation) GenerateKeyPairContext(
+	ctx context.Context,
+) ([]byte, error) {
+	snapshot := kem.snapshot()
+	var publicKey []byte
+	err := runContext(ctx, kem.pendingOps(), func() error {
+		var err error
+		publicKey, err = snapshot.GenerateKeyPair()
+		return err
+	}, func() {
+		MemCleanse(snapshot.secretKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	kem.secretKey = snapshot.secretKey
+	kem.publicKey = snapshot.publicKey
+	return publicKey, nil
+}
+
+// EncapSecretContext works like KeyEncapsulation.EncapSecret, but runs on the
+// worker pool and returns ctx.Err() once ctx is done.
+func (kem *KeyEncapsulation) EncapSecretContext(ctx context.Context,
+	publicKey []byte,
+) (ciphertext, sharedSecret []byte, err error) {
+	snapshot := kem.snapshot()
+	var ct, ss []byte
+	err = runContext(ctx, kem.pendingOps(), func() error {
+		var err error
+		ct, ss, err = snapshot.EncapSecret(publicKey)
+		return err
+	}, func() {
+		MemCleanse(ss)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ct, ss, nil
+}
+
+// DecapSecretContext works like KeyEncapsulation.DecapSecret, but runs on the
+// worker pool and returns ctx.Err() once ctx is done.
+func (kem *KeyEncapsulation) DecapSecretContext(ctx context.Context,
+	ciphertext []byte,
+) ([]byte, error) {
+	snapshot := kem.snapshot()
+	var sharedSecret []byte
+	err := runContext(ctx, kem.pendingOps(), func() error {
+		var err error
+		sharedSecret, err = snapshot.DecapSecret(ciphertext)
+		return err
+	}, func() {
+		MemCleanse(sharedSecret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sharedSecret, nil
+}
+
+/**************** END KeyEncapsulation context variants ****************/
+
+/**************** Signature context variants ****************/
+
+// pendingOps returns the operations run by the worker pool on the handle of
+// the sig receiver, which its copies share. An uninitialized receiver has no
+// handle, and its operations fail without using any backend.
+func (sig *Signature) pendingOps() *pendingGroup {
+	if sig.handle == nil {
+		return &pendingGroup{}
+	}
+	return &sig.handle.pending
+}
+
+// waitPending waits for the abandoned operations of the sig receiver and of
+// its copies.
+func (sig *Signature) waitPending() {
+	if sig.handle != nil {
+		sig.handle.pending.Wait()
+	}
+}
+
+// snapshot returns a copy of the sig receiver sharing its handle, on which the
+// context variants run.
+func (sig *Signature) snapshot() *Signature {
+	return &Signature{
+		handle:     sig.handle,
+		secretKey:  sig.secretKey,
+		algDetails: sig.algDetails,
+		opts:       sig.opts,
+		publicKey:  sig.publicKey,
+	}
+}
+
+// GenerateKeyPairContext works like Signature.GenerateKeyPair, but runs on the
+// worker pool and returns ctx.Err() once ctx is done. The secret key of the
+// sig receiver is only replaced on success.
+func (sig *Signature) GenerateKeyPairContext(
+	ctx context.Context,
+) ([]byte, error) {
+	snapshot := sig.snapshot()
+	var publicKey []byte
+	err := runContext(ctx, sig.pendingOps(), func() error {
+		var err error
+		publicKey, err = snapshot.GenerateKeyPair()
+		return err
+	}, func() {
+		MemCleanse(snapshot.secretKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sig.secretKey = snapshot.secretKey
+	sig.publicKey = snapshot.publicKey
+	return publicKey, nil
+}
+
+// SignContext works like Signature.Sign, but runs on the worker pool and
+// returns ctx.Err() once ctx is done.
+func (sig *Signature) SignContext(ctx context.Context,
+	message []byte,
+) ([]byte, error) {
+	snapshot := sig.snapshot()
+	var signature []byte
+	err := runContext(ctx, sig.pendingOps(), func() error {
+		var err error
+		signature, err = snapshot.Sign(message)
+		return err
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// SignWithCtxStrContext works like Signature.SignWithCtxStr, but runs on the
+// worker pool and returns ctx.Err() once ctx is done.
+func (sig *Signature) SignWithCtxStrContext(ctx context.Context,
+	message []byte, context []byte,
+) ([]byte, error) {
+	snapshot := sig.snapshot()
+	var signature []byte
+	err := runContext(ctx, sig.pendingOps(), func() error {
+		var err error
+		signature, err = snapshot.SignWithCtxStr(message, context)
+		return err
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// VerifyContext works like Signature.Verify, but runs on the worker pool and
+// returns ctx.Err() once ctx is done.
+func (sig *Signature) VerifyContext(ctx context.Context, message []byte,
+	signature []byte, publicKey []byte,
+) (bool, error) {
+	snapshot := sig.snapshot()
+	var isValid bool
+	err := runContext(ctx, sig.pendingOps(), func() error {
+		var err error
+		isValid, err = snapshot.Verify(message, signature, publicKey)
+		return err
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return isValid, nil
+}
+
+// VerifyWithCtxStrContext works like Signature.VerifyWithCtxStr, but runs on
+// the worker pool and returns ctx.Err() once ctx is done.
+func (sig *Signature) VerifyWithCtxStrContext(ctx context.Context,
+	message []byte, signature []byte, context []byte, publicKey []byte,
+) (bool, error) {
+	snapshot := sig.snapshot()
+	var isValid bool
+	err := runContext(ctx, sig.pendingOps(), func() error {
+		var err error
+		isValid, err = snapshot.VerifyWithCtxStr(message, signature, context,
+			publicKey)
+		return err
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	return isValid, nil
+}
+
+/**************** END Signature context variants ****************/
@@ -0,0 +1,61 @@
+package oqs
+
+import "errors"
+
+/**************** Fault countermeasures ****************/
+
+// ErrSignatureFault is returned by the signing methods of a Signature created
+// with WithVerifyAfterSign when a freshly produced signature does not verify,
+// e.g., because of a fault during signing. The faulty signature is zeroed and
+// never returned.
+var ErrSignatureFault = errors.New("signature verification after signing " +
+	"failed, the signature was discarded")
+
+// WithVerifyAfterSign makes a Signature verify every signature it produces,
+// including those of Signature.SignBatch and of the context variants, with
+// its public key before returning it, as a countermeasure against fault
+// attacks that recover the secret key from faulty signatures. The public key
+// is kept by Signature.GenerateKeyPair, or must be imported with
+// Signature.ImportPublicKey.
+func WithVerifyAfterSign() Option {
+	return func(o *options) {
+		o.verifyAfterSign = true
+	}
+}
+
+// checkVerifyAfterSign returns an error if the sig receiver verifies its
+// signatures but lacks the public key.
+func (sig *Signature) checkVerifyAfterSign() error {
+	if sig.opts.verifyAfterSign &&
+		len(sig.publicKey) != sig.algDetails.LengthPublicKey {
+		return errors.New("verify-after-sign requires the public key, make " +
+			"sure you run GenerateKeyPair() or ImportPublicKey()")
+	}
+	return nil
+}
+
+// verifyAfterSign verifies with backend a signature the sig receiver just
+// produced, if enabled, and zeroes it if it does not verify.
+func (sig *Signature) verifyAfterSign(backend sigBackend, signature, message,
+	context []byte,
+) error {
+	injectSignatureFault(sig.algDetails.Name, signature)
+	if !sig.opts.verifyAfterSign {
+		return nil
+	}
+
+	var isValid bool
+	if len(context) > 0 {
+		isValid = backend.verifyWithCtxStr(message, signature, context,
+			sig.publicKey)
+	} else {
+		isValid = backend.verify(message, signature, sig.publicKey)
+	}
+	if !isValid {
+		MemCleanse(signature)
+		return ErrSignatureFault
+	}
+	return nil
+}
+
+/**************** END Fault countermeasures ****************/
@@ -0,0 +1,132 @@
+package oqs
+
+import (
+	"errors"
+	"strings"
+)
+
+/**************** CPU features and implementations ****************/
+
+// liboqs ships optimized variants of several algorithms, e.g. AVX2 code for
+// ML-KEM and ML-DSA. Distribution builds of liboqs, i.e., built with
+// OQS_DIST_BUILD, select them at runtime from the features of the CPU, and
+// fall back to the portable variant otherwise. Other builds use the variants
+// they were compiled with unconditionally. liboqs does not report the variant
+// it selects, hence AlgorithmImplementation infers it the same way, and there
+// is no way to force the portable variant at runtime; it must be selected when
+// building liboqs, e.g. with -DOQS_DIST_BUILD=OFF -DOQS_OPT_TARGET=generic.
+
+// The implementation variants reported by AlgorithmImplementation, besides the
+// optimized ones named after the liboqs variants, e.g. "avx2" or "aarch64".
+const (
+	ImplementationPortable = "portable"
+	ImplementationPureGo   = "purego"
+)
+
+// variantFeatures maps the algorithm families to the CPU features each of
+// their optimized variants requires, as checked by liboqs.
+var variantFeatures = map[string]map[string][]string{
+	"ML-KEM": {
+		"x86_64":  {"avx2", "bmi2", "popcnt"},
+		"aarch64": {"arm_neon"},
+	},
+	"ML-DSA":           {"avx2": {"avx2", "popcnt"}},
+	"Falcon":           {"avx2": {"avx2"}, "aarch64": {"arm_neon"}},
+	"SPHINCS+":         {"avx2": {"avx2"}},
+	"Classic-McEliece": {"avx2": {"avx2", "popcnt", "bmi1"}},
+	"MAYO":             {"avx2": {"avx2"}},
+}
+
+// Implementation describes the variant of an algorithm in use.
+type Implementation struct {
+	Algorithm string
+	// Variant is ImplementationPortable, ImplementationPureGo, or the name of
+	// an optimized liboqs variant, e.g. "avx2".
+	Variant string
+	// Features are the CPU features the variant relies on.
+	Features []string
+}
+
+// Portable reports whether the portable variant is in use.
+func (i Implementation) Portable() bool {
+	return i.Variant == ImplementationPortable
+}
+
+// CPUFeatures returns the CPU features detected by liboqs with
+// OQS_CPU_has_extension(), keyed by their lowercase liboqs names, e.g. "avx2",
+// "aes" or "arm_neon". It returns an empty map for the pure-Go backend.
+func CPUFeatures() map[string]bool {
+	features := cpuFeatures()
+	if features == nil {
+		features = make(map[string]bool)
+	}
+	return features
+}
+
+// AlgorithmImplementation returns the implementation variant of an enabled KEM
+// or signature algorithm. With the liboqs backend, the variant is inferred from
+// the liboqs build configuration and the CPU features, as liboqs does not
+// report it.
+func AlgorithmImplementation(algName string) (Implementation, error) {
+	if !IsKEMEnabled(algName) && !IsSigEnabled(algName) {
+		return Implementation{}, errors.New(`"` + algName +
+			`" is not enabled by OQS`)
+	}
+	impl := Implementation{Algorithm: algName,
+		Variant: ImplementationPortable}
+	if backendName() == "purego" {
+		impl.Variant = ImplementationPureGo
+		return impl, nil
+	}
+
+	family := AlgorithmFamily(algName)
+	variant, ok := compiledVariants()[family]
+	if !ok {
+		return impl, nil
+	}
+	features := variantFeatures[family][variant]
+	if runtimeDispatch() {
+		available := cpuFeatures()
+		for _, feature := range features {
+			if !available[feature] {
+				return impl, nil
+			}
+		}
+	}
+	impl.Variant = variant
+	impl.Features = append([]string(nil), features...)
+	return impl, nil
+}
+
+// WithPortableImplementation makes KeyEncapsulation.Init and Signature.Init
+// fail unless the portable variant of the algorithm is in use, e.g. for tests
+// that expect the same behaviour on every CPU. As liboqs can not be forced to
+// use the portable variant at runtime, this only checks that it was built or
+// runs such that it does.
+func WithPortableImplementation() Option {
+	return func(o *options) {
+		o.portable = true
+	}
+}
+
+// checkPortable returns an error if the portable variant of algName is
+// required but not in use.
+func checkPortable(algName string, o options) error {
+	if !o.portable {
+		return nil
+	}
+	impl, err := AlgorithmImplementation(algName)
+	if err != nil {
+		return err
+	}
+	if impl.Variant != ImplementationPortable {
+		return errors.New(`"` + algName + `" uses the ` + impl.Variant +
+			` implementation (` + strings.Join(impl.Features, ", ") +
+			`), and liboqs can not be forced to use the portable one at ` +
+			`runtime, build it with -DOQS_DIST_BUILD=OFF ` +
+			`-DOQS_OPT_TARGET=generic`)
+	}
+	return nil
+}
+
+/**************** END CPU features and implementations ****************/
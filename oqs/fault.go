@@ -0,0 +1,7 @@
+//go:build !oqs_faultinject
+
+package oqs
+
+// injectSignatureFault does nothing unless the package is built with the
+// oqs_faultinject build tag, see SetSignatureFault.
+func injectSignatureFault(algName string, signature []byte) {}
@@ -0,0 +1,35 @@
+//go:build oqs_faultinject
+
+package oqs
+
+import "sync/atomic"
+
+/**************** Fault injection ****************/
+
+// Fault injection is only compiled in with the oqs_faultinject build tag, and
+// is meant for testing fault countermeasures such as WithVerifyAfterSign. It
+// must never be enabled in production builds.
+
+// signatureFault is the function set with SetSignatureFault, or nil.
+var signatureFault atomic.Pointer[func(algName string, signature []byte)]
+
+// SetSignatureFault sets a function called with every signature produced by a
+// Signature, before it is verified by WithVerifyAfterSign and returned, which
+// can corrupt the signature in place to simulate a fault. A nil fn disables
+// fault injection.
+func SetSignatureFault(fn func(algName string, signature []byte)) {
+	if fn == nil {
+		signatureFault.Store(nil)
+		return
+	}
+	signatureFault.Store(&fn)
+}
+
+// injectSignatureFault calls the function set with SetSignatureFault, if any.
+func injectSignatureFault(algName string, signature []byte) {
+	if fn := signatureFault.Load(); fn != nil {
+		(*fn)(algName, signature)
+	}
+}
+
+/**************** END Fault injection ****************/
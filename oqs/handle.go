@@ -0,0 +1,165 @@
+package oqs
+
+import (
+	"errors"
+	"sync"
+)
+
+/**************** Handles ****************/
+
+// A KeyEncapsulation or a Signature owns its backend, e.g. a *C.OQS_KEM,
+// through a handle, which copies of the object share. The handle frees the
+// backend exactly once, when the first copy is cleaned and no operation uses
+// the backend anymore, hence cleaning several copies never frees it twice.
+// Using any copy after Clean returns ErrCleaned rather than calling into a
+// freed backend. Copying a KeyEncapsulation or a Signature is nonetheless
+// discouraged, and reported by go vet.
+
+// ErrNotInitialized is returned by the methods of a KeyEncapsulation or a
+// Signature that has not been initialized with Init.
+var ErrNotInitialized = errors.New("object is not initialized, make sure " +
+	"you run Init()")
+
+// ErrCleaned is returned by the methods of a KeyEncapsulation or a Signature,
+// or of a copy of it, used after Clean.
+var ErrCleaned = errors.New("object was cleaned, make sure you run Init() " +
+	"again")
+
+// noCopy makes go vet's copylocks check report the copies of the structs
+// embedding it, see sync.WaitGroup.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+// handle owns a backend, together with the additional backends of the batch
+// operations, if any.
+type handle[B interface{ free() }] struct {
+	mu      sync.Mutex
+	backend B
+	batch   []B  // contexts of Signature.SignBatch and Signature.VerifyBatch
+	refs    int  // operations using the backends
+	cleaned bool // set by close
+	// operations abandoned by the context variants, which may still use the
+	// backend or the secret key of any copy
+	pending pendingGroup
+}
+
+// pendingGroup counts operations in progress, like a sync.WaitGroup, but allows
+// Add to run concurrently with Wait, as a copy may start an operation while
+// another copy waits.
+type pendingGroup struct {
+	mu    sync.Mutex
+	done  sync.Cond
+	count int
+}
+
+// Add adds delta to the count, and wakes up the waiters once it drops to zero.
+func (p *pendingGroup) Add(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.count += delta
+	if p.count == 0 {
+		p.done.Broadcast()
+	}
+}
+
+// Done decrements the count by one.
+func (p *pendingGroup) Done() {
+	p.Add(-1)
+}
+
+// Wait waits until the count is zero.
+func (p *pendingGroup) Wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.count != 0 {
+		p.done.Wait()
+	}
+}
+
+// newHandle returns a handle owning backend.
+func newHandle[B interface{ free() }](backend B) *handle[B] {
+	h := &handle[B]{backend: backend}
+	h.pending.done.L = &h.pending.mu
+	return h
+}
+
+// acquire returns the backend of a non-nil, non-cleaned handle, which is not
+// freed until the matching call to release, and which Shutdown waits for.
+func (h *handle[B]) acquire() (B, error) {
+	var backend B
+	if h == nil {
+		return backend, ErrNotInitialized
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cleaned {
+		return backend, ErrCleaned
+	}
+	if err := beginOp(); err != nil {
+		return backend, err
+	}
+	h.refs++
+	return h.backend, nil
+}
+
+// release ends an operation started with acquire, and frees the backends if
+// the handle was closed in the meantime.
+func (h *handle[B]) release() {
+	endOp()
+	h.mu.Lock()
+	h.refs--
+	free := h.cleaned && h.refs == 0
+	h.mu.Unlock()
+	if free {
+		h.free()
+	}
+}
+
+// close marks the handle as cleaned, and frees the backends unless an
+// operation still uses them. Closing a nil or cleaned handle does nothing.
+func (h *handle[B]) close() {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	if h.cleaned {
+		h.mu.Unlock()
+		return
+	}
+	h.cleaned = true
+	free := h.refs == 0
+	h.mu.Unlock()
+	if free {
+		h.free()
+	}
+}
+
+// batchBackends returns the batch backends of an acquired handle, creating
+// them with create on first use.
+func (h *handle[B]) batchBackends(create func() ([]B, error)) ([]B, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.batch == nil {
+		batch, err := create()
+		if err != nil {
+			return nil, err
+		}
+		h.batch = batch
+	}
+	return h.batch, nil
+}
+
+// free frees the backends, once the handle is cleaned and unused.
+func (h *handle[B]) free() {
+	h.backend.free()
+	for _, backend := range h.batch {
+		backend.free()
+	}
+	var zero B
+	h.backend = zero
+	h.batch = nil
+}
+
+/**************** END Handles ****************/
@@ -0,0 +1,193 @@
+package oqs
+
+import (
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+)
+
+/**************** Health tests ****************/
+
+// The health tests implement the continuous repetition count test and adaptive
+// proportion test of NIST SP 800-90B, Section 4.4, on every byte produced by
+// the custom RNG algorithm set with RandomBytesCustomAlgorithm, and by the
+// readers set with WithRandomReader or WithRandomSource. Once a test fails, the
+// failure is latched: key generation and encapsulation return a
+// *HealthTestError, as does Reader, until the health tests are reset with
+// ResetRandomHealthTests.
+
+// healthTestWindow is the adaptive proportion test window size for non-binary
+// samples, see SP 800-90B, Section 4.4.2.
+const healthTestWindow = 512
+
+// healthTestAlpha is the -log2 of the false positive probability of both tests.
+const healthTestAlpha = 20
+
+// HealthTestError is returned when a health test of the custom RNG algorithm
+// failed.
+type HealthTestError struct {
+	Test   string // "repetition count" or "adaptive proportion"
+	Cutoff int    // the cutoff value that was reached
+}
+
+func (e *HealthTestError) Error() string {
+	return "RNG health test failed: " + e.Test + " test reached its cutoff " +
+		"of " + strconv.Itoa(e.Cutoff)
+}
+
+// healthTester holds the state of the health tests.
+type healthTester struct {
+	mu          sync.Mutex
+	rctCutoff   int
+	aptCutoff   int
+	rctSample   byte
+	rctCount    int
+	aptSample   byte
+	aptCount    int
+	aptObserved int // number of samples of the current window seen so far
+	err         *HealthTestError
+}
+
+// health is the state of the health tests, or nil if they are disabled.
+var (
+	healthMu sync.Mutex
+	health   *healthTester
+)
+
+// EnableRandomHealthTests enables the health tests on the custom RNG
+// algorithm and on the readers of WithRandomReader and WithRandomSource,
+// assuming a min-entropy of minEntropy bits per byte, with
+// 0 < minEntropy <= 8. Enabling the health tests again resets them.
+func EnableRandomHealthTests(minEntropy float64) error {
+	if !(minEntropy > 0 && minEntropy <= 8) {
+		return errors.New("the min-entropy must be in (0, 8] bits per byte")
+	}
+	tester := &healthTester{
+		rctCutoff: 1 + int(math.Ceil(healthTestAlpha/minEntropy)),
+		aptCutoff: 1 + critBinom(healthTestWindow, math.Exp2(-minEntropy),
+			math.Exp2(-healthTestAlpha)),
+	}
+	healthMu.Lock()
+	health = tester
+	healthMu.Unlock()
+	return nil
+}
+
+// DisableRandomHealthTests disables the health tests, and clears a latched
+// failure.
+func DisableRandomHealthTests() {
+	healthMu.Lock()
+	health = nil
+	healthMu.Unlock()
+}
+
+// ResetRandomHealthTests clears a latched health test failure, and restarts
+// the health tests. It does nothing if the health tests are disabled.
+func ResetRandomHealthTests() {
+	if tester := currentHealthTester(); tester != nil {
+		tester.mu.Lock()
+		tester.rctCount = 0
+		tester.aptObserved = 0
+		tester.err = nil
+		tester.mu.Unlock()
+	}
+}
+
+// RandomHealthTestError returns the latched health test failure, or nil if
+// the health tests did not fail or are disabled.
+func RandomHealthTestError() error {
+	if tester := currentHealthTester(); tester != nil {
+		tester.mu.Lock()
+		defer tester.mu.Unlock()
+		if tester.err != nil {
+			return tester.err
+		}
+	}
+	return nil
+}
+
+// currentHealthTester returns the state of the health tests, or nil.
+func currentHealthTester() *healthTester {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	return health
+}
+
+// healthTest runs the health tests, if enabled, on bytes produced by the
+// custom RNG algorithm or by a user-supplied reader.
+func healthTest(randomArray []byte) {
+	tester := currentHealthTester()
+	if tester == nil {
+		return
+	}
+	tester.mu.Lock()
+	defer tester.mu.Unlock()
+	for _, sample := range randomArray {
+		if tester.err != nil {
+			return
+		}
+		tester.feed(sample)
+	}
+}
+
+// healthTestedReader runs the health tests on the bytes read from a
+// user-supplied reader. The internal readers, e.g. the zero randomness of
+// deterministic signing, are not wrapped.
+type healthTestedReader struct {
+	r io.Reader
+}
+
+func (hr healthTestedReader) Read(b []byte) (int, error) {
+	n, err := hr.r.Read(b)
+	healthTest(b[:n])
+	return n, err
+}
+
+// feed runs both health tests on a single sample, see SP 800-90B, Sections
+// 4.4.1 and 4.4.2.
+func (h *healthTester) feed(sample byte) {
+	if h.rctCount > 0 && sample == h.rctSample {
+		h.rctCount++
+		if h.rctCount >= h.rctCutoff {
+			h.err = &HealthTestError{Test: "repetition count",
+				Cutoff: h.rctCutoff}
+		}
+	} else {
+		h.rctSample = sample
+		h.rctCount = 1
+	}
+
+	if h.aptObserved == 0 {
+		h.aptSample = sample
+		h.aptCount = 1
+	} else if sample == h.aptSample {
+		h.aptCount++
+		if h.aptCount >= h.aptCutoff {
+			h.err = &HealthTestError{Test: "adaptive proportion",
+				Cutoff: h.aptCutoff}
+		}
+	}
+	h.aptObserved = (h.aptObserved + 1) % healthTestWindow
+}
+
+// critBinom returns the smallest k such that the probability of at most k
+// successes out of n trials, each with success probability p, is at least
+// 1 - alpha.
+func critBinom(n int, p float64, alpha float64) int {
+	lgN, _ := math.Lgamma(float64(n + 1))
+	cdf := 0.0
+	for k := 0; k < n; k++ {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		cdf += math.Exp(lgN - lgK - lgNK + float64(k)*math.Log(p) +
+			float64(n-k)*math.Log1p(-p))
+		if cdf >= 1-alpha {
+			return k
+		}
+	}
+	return n
+}
+
+/**************** END Health tests ****************/
@@ -0,0 +1,138 @@
+// Package hooks provides oqs.Hook adapters reporting the operations of the oqs
+// package to log/slog and to expvar. Install one with oqs.SetHook, e.g.
+//
+//	oqs.SetHook(hooks.NewExpvar("oqs"))
+//
+// Only the fields of oqs.Event are reported, hence no key material, message,
+// ciphertext, shared secret or signature is ever logged or published.
+package hooks
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"sync"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+/**************** slog ****************/
+
+// Slog is an oqs.Hook logging each operation as a structured record.
+type Slog struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+// NewSlog returns a hook logging the operations to logger at the given level,
+// except for failed operations, which are logged at slog.LevelError. A nil
+// logger logs to slog.Default().
+func NewSlog(logger *slog.Logger, level slog.Level) *Slog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Slog{logger: logger, level: level}
+}
+
+// Observe logs event.
+func (s *Slog) Observe(event oqs.Event) {
+	level := s.level
+	if event.Err != nil {
+		level = slog.LevelError
+	}
+	ctx := context.Background()
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("algorithm", event.Algorithm),
+		slog.String("operation", string(event.Operation)),
+		slog.Duration("duration", event.Duration),
+		slog.Int("input_size", event.InputSize),
+		slog.Int("output_size", event.OutputSize),
+		slog.String("outcome", event.Outcome()),
+	}
+	if event.Err != nil {
+		attrs = append(attrs, slog.String("error", event.Err.Error()))
+	}
+	s.logger.LogAttrs(ctx, level, "oqs operation", attrs...)
+}
+
+/**************** END slog ****************/
+
+/**************** expvar ****************/
+
+// Expvar is an oqs.Hook publishing per-algorithm, per-operation counters as
+// an expvar.Map. The map has a key "<algorithm>.<operation>" for each
+// algorithm and operation seen so far, e.g. "ML-KEM-768.encaps", whose value
+// is a map with the following counters:
+//
+//	count         number of operations
+//	errors        number of failed operations
+//	invalid       number of signatures rejected by verify operations
+//	duration_ns   total duration of the operations, in nanoseconds
+//	input_bytes   total input size, see oqs.Event.InputSize
+//	output_bytes  total output size, see oqs.Event.OutputSize
+type Expvar struct {
+	mu      sync.Mutex // serializes the creation of the per-key maps
+	metrics *expvar.Map
+}
+
+// NewExpvar returns a hook publishing its counters under name, which then
+// appear in the /debug/vars output of net/http. If a map is already published
+// under name, e.g. by a previous call, it is reused. NewExpvar panics if name
+// is published with another type of variable.
+func NewExpvar(name string) *Expvar {
+	if v := expvar.Get(name); v != nil {
+		return &Expvar{metrics: v.(*expvar.Map)}
+	}
+	return &Expvar{metrics: expvar.NewMap(name)}
+}
+
+// Map returns the published map.
+func (e *Expvar) Map() *expvar.Map {
+	return e.metrics
+}
+
+// Observe adds event to the counters.
+func (e *Expvar) Observe(event oqs.Event) {
+	m := e.counters(event.Algorithm + "." + string(event.Operation))
+	m.Add("count", 1)
+	switch event.Outcome() {
+	case "error":
+		m.Add("errors", 1)
+	case "invalid":
+		m.Add("invalid", 1)
+	}
+	m.Add("duration_ns", event.Duration.Nanoseconds())
+	m.Add("input_bytes", int64(event.InputSize))
+	m.Add("output_bytes", int64(event.OutputSize))
+}
+
+// counters returns the map of counters of key, creating it if needed.
+func (e *Expvar) counters(key string) *expvar.Map {
+	if v := e.metrics.Get(key); v != nil {
+		return v.(*expvar.Map)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if v := e.metrics.Get(key); v != nil {
+		return v.(*expvar.Map)
+	}
+	m := new(expvar.Map).Init()
+	for _, counter := range []string{"count", "errors", "invalid",
+		"duration_ns", "input_bytes", "output_bytes"} {
+		m.Add(counter, 0)
+	}
+	e.metrics.Set(key, m)
+	return m
+}
+
+var (
+	_ oqs.Hook = (*Slog)(nil)
+	_ oqs.Hook = (*Expvar)(nil)
+)
+
+/**************** END expvar ****************/
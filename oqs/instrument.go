@@ -0,0 +1,105 @@
+package oqs
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+/**************** Instrumentation ****************/
+
+// Operation identifies an instrumented operation.
+type Operation string
+
+// The instrumented operations. The buffer, batch and context variants of the
+// KeyEncapsulation and Signature methods report the operation they perform.
+const (
+	OperationInit    Operation = "init"
+	OperationKeypair Operation = "keypair"
+	OperationEncaps  Operation = "encaps"
+	OperationDecaps  Operation = "decaps"
+	OperationSign    Operation = "sign"
+	OperationVerify  Operation = "verify"
+)
+
+// Event describes a finished operation. It never contains key material,
+// messages, ciphertexts, shared secrets or signatures, only their sizes.
+type Event struct {
+	Algorithm string
+	Operation Operation
+	Duration  time.Duration
+	// InputSize is the size in bytes of the public key for encaps, of the
+	// ciphertext for decaps, and of the message for sign and verify.
+	InputSize int
+	// OutputSize is the size in bytes of the public key for keypair, of the
+	// ciphertext for encaps, of the shared secret for decaps, and of the
+	// signature for sign and verify.
+	OutputSize int
+	// Valid is the result of a verify operation.
+	Valid bool
+	// Err is the error returned by the operation, if any.
+	Err error
+}
+
+// Outcome returns "error" if the operation failed, "invalid" if a verify
+// operation rejected its signature, and "ok" otherwise.
+func (e Event) Outcome() string {
+	switch {
+	case e.Err != nil:
+		return "error"
+	case e.Operation == OperationVerify && !e.Valid:
+		return "invalid"
+	default:
+		return "ok"
+	}
+}
+
+// Hook receives an Event after each instrumented operation. Observe is called
+// synchronously, from the goroutine that ran the operation, hence it must be
+// safe for concurrent use and should return quickly. The oqs/hooks package
+// provides log/slog and expvar adapters.
+type Hook interface {
+	Observe(event Event)
+}
+
+// hookHolder wraps a Hook, as atomic.Pointer needs a concrete type.
+type hookHolder struct {
+	hook Hook
+}
+
+// hook is the process-wide instrumentation hook, or nil.
+var hook atomic.Pointer[hookHolder]
+
+// SetHook installs h as the process-wide instrumentation hook, replacing the
+// previous one. A nil h disables instrumentation, which is the default.
+func SetHook(h Hook) {
+	if h == nil {
+		hook.Store(nil)
+		return
+	}
+	hook.Store(&hookHolder{hook: h})
+}
+
+// currentHook returns the process-wide instrumentation hook, or nil.
+func currentHook() Hook {
+	if holder := hook.Load(); holder != nil {
+		return holder.hook
+	}
+	return nil
+}
+
+// report sends the event of an operation started at start to h.
+func report(h Hook, start time.Time, algName string, op Operation,
+	inputSize, outputSize int, valid bool, err error,
+) {
+	h.Observe(Event{
+		Algorithm:  algName,
+		Operation:  op,
+		Duration:   time.Since(start),
+		InputSize:  inputSize,
+		OutputSize: outputSize,
+		Valid:      valid,
+		Err:        err,
+	})
+}
+
+/**************** END Instrumentation ****************/
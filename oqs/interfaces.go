@@ -0,0 +1,59 @@
+package oqs
+
+/**************** Interfaces ****************/
+
+// KEM is the interface implemented by KeyEncapsulation. Code depending on KEM
+// rather than on KeyEncapsulation can be tested with the fakes of the
+// oqs/oqstest package, or be given hybrid or remote implementations.
+type KEM interface {
+	// Details returns the KEM algorithm details.
+	Details() KeyEncapsulationDetails
+	// GenerateKeyPair generates a pair of secret key/public key, keeps the
+	// secret key and returns the public key.
+	GenerateKeyPair() ([]byte, error)
+	// EncapSecret encapsulates a fresh shared secret to publicKey.
+	EncapSecret(publicKey []byte) (ciphertext, sharedSecret []byte,
+		err error)
+	// DecapSecret decapsulates the shared secret of ciphertext with the
+	// secret key.
+	DecapSecret(ciphertext []byte) ([]byte, error)
+	// Clean zeroes-in the secret key and releases the resources.
+	Clean()
+}
+
+// Signer is the signing part of the interface implemented by Signature.
+type Signer interface {
+	// Details returns the signature algorithm details.
+	Details() SignatureDetails
+	// GenerateKeyPair generates a pair of secret key/public key, keeps the
+	// secret key and returns the public key.
+	GenerateKeyPair() ([]byte, error)
+	// Sign signs a message with the secret key.
+	Sign(message []byte) ([]byte, error)
+	// SignWithCtxStr signs a message with context string with the secret
+	// key.
+	SignWithCtxStr(message []byte, context []byte) ([]byte, error)
+	// Clean zeroes-in the secret key and releases the resources.
+	Clean()
+}
+
+// Verifier is the verification part of the interface implemented by
+// Signature.
+type Verifier interface {
+	// Details returns the signature algorithm details.
+	Details() SignatureDetails
+	// Verify verifies the validity of a signed message.
+	Verify(message []byte, signature []byte, publicKey []byte) (bool, error)
+	// VerifyWithCtxStr verifies the validity of a signed message with
+	// context string.
+	VerifyWithCtxStr(message []byte, signature []byte, context []byte,
+		publicKey []byte) (bool, error)
+}
+
+var (
+	_ KEM      = (*KeyEncapsulation)(nil)
+	_ Signer   = (*Signature)(nil)
+	_ Verifier = (*Signature)(nil)
+)
+
+/**************** END Interfaces ****************/
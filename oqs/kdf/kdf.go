@@ -0,0 +1,153 @@
+//go:build go1.24
+
+// Package kdf derives keys from the shared secrets of the oqs package, with
+// HKDF-SHA256 and HKDF-SHA384 (RFC 5869), SHAKE256 (FIPS 202) and KMAC256
+// (NIST SP 800-185). AEADKey implements a ready-made derivation of an AEAD key
+// from the output of a KEM, which binds the algorithm, the ciphertext and the
+// public key of the recipient, e.g.
+//
+//	ciphertext, sharedSecret, err := kem.EncapSecret(publicKey)
+//	...
+//	key, err := kdf.AEADKey(kem.Details().Name, sharedSecret, ciphertext,
+//		publicKey, nil, 32)
+//
+// The package relies on the crypto/hkdf and crypto/sha3 packages, which are
+// available starting with Go 1.24.
+package kdf
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+)
+
+/**************** HKDF ****************/
+
+// HKDFSHA256 derives length bytes from secret with HKDF-SHA256, i.e., the
+// HKDF-Extract of secret with salt followed by the HKDF-Expand with info. A nil
+// salt is equivalent to a zero salt of the hash length. length must not exceed
+// 255 * 32 bytes.
+func HKDFSHA256(secret, salt, info []byte, length int) ([]byte, error) {
+	return hkdf.Key(sha256.New, secret, salt, string(info), length)
+}
+
+// HKDFSHA384 derives length bytes from secret with HKDF-SHA384, as
+// HKDFSHA256 does. length must not exceed 255 * 48 bytes.
+func HKDFSHA384(secret, salt, info []byte, length int) ([]byte, error) {
+	return hkdf.Key(sha512.New384, secret, salt, string(info), length)
+}
+
+/**************** END HKDF ****************/
+
+/**************** SHAKE and KMAC ****************/
+
+// SHAKE256 derives length bytes from secret with SHAKE256(secret || info), as
+// e.g. ML-KEM does for its implicit rejection. Since secret and info are
+// concatenated, secret must have a fixed length, e.g. the shared secret length
+// of a KEM, or info must be self-delimiting. length must not be negative.
+func SHAKE256(secret, info []byte, length int) []byte {
+	h := sha3.NewSHAKE256()
+	h.Write(secret)
+	h.Write(info)
+	out := make([]byte, length)
+	h.Read(out)
+	return out
+}
+
+// kmacRate is the rate of cSHAKE256 in bytes, which KMAC256 pads the key to.
+const kmacRate = 136
+
+// KMAC256 computes the KMAC256 of data under key with the customization
+// string, as specified by NIST SP 800-185, with an output of length bytes.
+// length must not be negative.
+func KMAC256(key, data, customization []byte, length int) []byte {
+	h := sha3.NewCSHAKE256([]byte("KMAC"), customization)
+	h.Write(bytepad(encodeString(key), kmacRate))
+	h.Write(data)
+	h.Write(rightEncode(uint64(length) * 8))
+	out := make([]byte, length)
+	h.Read(out)
+	return out
+}
+
+// leftEncode returns the left_encode(x) of NIST SP 800-185, i.e., the minimal
+// big-endian encoding of x, of at least one byte, preceded by its length.
+func leftEncode(x uint64) []byte {
+	var buf [9]byte
+	binary.BigEndian.PutUint64(buf[1:], x)
+	n := 8
+	for n > 1 && buf[9-n] == 0 {
+		n--
+	}
+	buf[8-n] = byte(n)
+	return buf[8-n:]
+}
+
+// rightEncode returns the right_encode(x) of NIST SP 800-185, i.e., the
+// minimal big-endian encoding of x, of at least one byte, followed by its
+// length.
+func rightEncode(x uint64) []byte {
+	encoded := leftEncode(x)
+	return append(encoded[1:], encoded[0])
+}
+
+// encodeString returns the encode_string(s) of NIST SP 800-185, i.e., s
+// preceded by the left_encode of its length in bits.
+func encodeString(s []byte) []byte {
+	return append(leftEncode(uint64(len(s))*8), s...)
+}
+
+// bytepad returns the bytepad(x, w) of NIST SP 800-185, i.e., x preceded by
+// the left_encode of w and padded with zeros to a multiple of w bytes.
+func bytepad(x []byte, w int) []byte {
+	padded := append(leftEncode(uint64(w)), x...)
+	if rem := len(padded) % w; rem != 0 {
+		padded = append(padded, make([]byte, w-rem)...)
+	}
+	return padded
+}
+
+/**************** END SHAKE and KMAC ****************/
+
+/**************** KEM to AEAD ****************/
+
+// aeadLabel identifies the AEAD key derivation, and its version.
+const aeadLabel = "liboqs-go KEM-AEAD v1"
+
+// AEADKey derives an AEAD key of length bytes, e.g. 32 for AES-256-GCM, from
+// the sharedSecret output by the algName KEM for ciphertext and publicKey, and
+// from an optional application-specific info. The key is the HKDF-SHA256 of
+// sharedSecret, with no salt, and with the info
+//
+//	"liboqs-go KEM-AEAD v1" || len(algName) || algName || len(ciphertext) ||
+//	ciphertext || len(publicKey) || publicKey || len(info) || info
+//
+// where the lengths are 4-byte big-endian integers. Binding the algorithm, the
+// ciphertext and the public key of the recipient makes the key unique to the
+// encapsulation even if the KEM did not bind them itself, e.g. when a
+// ciphertext is reused across public keys.
+func AEADKey(algName string, sharedSecret, ciphertext, publicKey, info []byte,
+	length int,
+) ([]byte, error) {
+	if len(sharedSecret) == 0 {
+		return nil, errors.New("the shared secret can not be empty")
+	}
+	labeled := make([]byte, 0, len(aeadLabel)+16+len(algName)+
+		len(ciphertext)+len(publicKey)+len(info))
+	labeled = append(labeled, aeadLabel...)
+	for _, field := range [][]byte{[]byte(algName), ciphertext, publicKey,
+		info} {
+		if uint64(len(field)) > 1<<32-1 {
+			return nil, errors.New("the AEAD key derivation inputs are too " +
+				"long")
+		}
+		labeled = binary.BigEndian.AppendUint32(labeled, uint32(len(field)))
+		labeled = append(labeled, field...)
+	}
+	return HKDFSHA256(sharedSecret, nil, labeled, length)
+}
+
+/**************** END KEM to AEAD ****************/
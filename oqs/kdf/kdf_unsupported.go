@@ -0,0 +1,8 @@
+//go:build !go1.24
+
+// Package kdf derives keys from the shared secrets of the oqs package. It
+// relies on the crypto/hkdf and crypto/sha3 packages, which are available
+// starting with Go 1.24, hence it is empty when built with an older Go
+// toolchain: HKDFSHA256, HKDFSHA384, SHAKE256, KMAC256 and AEADKey are only
+// defined with Go 1.24 or later.
+package kdf
@@ -0,0 +1,48 @@
+package oqs
+
+/**************** Library loading ****************/
+
+// LibraryEnv is the environment variable holding the path liboqs is loaded
+// from at program start in builds with the oqs_dlopen tag. If it is not set,
+// liboqs is loaded from the default library name, i.e., "liboqs.so", or
+// "liboqs.dylib" on macOS, which the dynamic linker looks up in its search
+// path.
+const LibraryEnv = "LIBOQS_GO_LIBRARY"
+
+// LibraryError is returned when liboqs can not be loaded at runtime, and by
+// KeyEncapsulation.Init and Signature.Init while it is not loaded.
+type LibraryError struct {
+	Path   string // path of the library
+	Symbol string // name of the missing symbol, if any
+	Reason string
+}
+
+func (e *LibraryError) Error() string {
+	return `can not load liboqs from "` + e.Path + `": ` + e.Reason
+}
+
+// LoadLibrary loads liboqs from path, in builds with the oqs_dlopen tag, if it
+// could not be loaded at program start, see LibraryEnv. A library can only be
+// loaded once; loading it again from the same path does nothing. LoadLibrary
+// must be called before the package is used concurrently, as it refreshes the
+// lists of supported and enabled algorithms. A *LibraryError is returned if the
+// library or one of the symbols the package needs is missing, and in builds
+// without the oqs_dlopen tag, and a *CompatibilityError if the library does not
+// match the headers the package was compiled against.
+func LoadLibrary(path string) error {
+	if err := loadLibrary(path); err != nil {
+		return err
+	}
+	checkCompatibility()
+	initKEMs()
+	initSigs()
+	return compatibilityError()
+}
+
+// LibraryPath returns the path liboqs was loaded from in builds with the
+// oqs_dlopen tag, and an empty string if it is not loaded, or in other builds.
+func LibraryPath() string {
+	return libraryPath()
+}
+
+/**************** END Library loading ****************/
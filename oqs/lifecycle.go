@@ -0,0 +1,154 @@
+package oqs
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+/**************** Lifecycle ****************/
+
+// liboqs is initialized with OQS_init() on first use, i.e., by the first
+// KeyEncapsulation.Init, Signature.Init or random bytes request, or explicitly
+// with Initialize. Shutdown releases its global resources with OQS_destroy(),
+// after which the operations fail with ErrShutdown until Initialize is called
+// again.
+
+// ErrShutdown is returned by the operations attempted after Shutdown.
+var ErrShutdown = errors.New("liboqs was shut down, make sure you run " +
+	"Initialize()")
+
+// The lifecycle states.
+const (
+	stateUninitialized int32 = iota
+	stateInitialized
+	stateShutdown
+)
+
+var (
+	// lifecycleMu serializes Initialize and Shutdown.
+	lifecycleMu sync.Mutex
+	// lifecycleState is the lifecycle state, written with lifecycleMu held.
+	lifecycleState atomic.Int32
+	// activeOps counts the operations in progress, which Shutdown waits for.
+	activeOps atomic.Int64
+	// opsMu and opsDone signal Shutdown when the last operation in progress
+	// ends.
+	opsMu   sync.Mutex
+	opsDone = sync.NewCond(&opsMu)
+)
+
+// Initialize initializes liboqs, or initializes it again after Shutdown. It is
+// called on first use, hence calling it explicitly is only needed after
+// Shutdown, or to control when the initialization happens. With the
+// oqs_dlopen build tag, it returns the reason why liboqs is not loaded, if so,
+// in which case LoadLibrary initializes it.
+func Initialize() error {
+	_ = initialize(false) // only fails lazily
+	return libraryError()
+}
+
+// initialize initializes liboqs unless it is already initialized, or, if lazy
+// is true, shut down.
+func initialize(lazy bool) error {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	switch lifecycleState.Load() {
+	case stateInitialized:
+		return nil
+	case stateShutdown:
+		if lazy {
+			return ErrShutdown
+		}
+	}
+	initBackend()
+	lifecycleState.Store(stateInitialized)
+	return nil
+}
+
+// initializeLoaded initializes a library loaded with LoadLibrary, if liboqs is
+// already in use.
+func initializeLoaded() {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	if lifecycleState.Load() == stateInitialized {
+		initBackend()
+	}
+}
+
+// Shutdown waits for the operations in progress, and releases the global
+// resources of liboqs, e.g. the OpenSSL objects it fetched, with
+// OQS_destroy(). The KeyEncapsulation and Signature objects can still be
+// cleaned afterwards, while their other methods, Init and the random bytes
+// functions fail with ErrShutdown. Shutdown must not be called from within an
+// operation, e.g. from a custom RNG algorithm, as it would wait for itself.
+func Shutdown() {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+	state := lifecycleState.Swap(stateShutdown)
+	opsMu.Lock()
+	for activeOps.Load() != 0 {
+		opsDone.Wait()
+	}
+	opsMu.Unlock()
+	if state == stateInitialized {
+		destroyBackend()
+	}
+}
+
+// ThreadStop releases the resources liboqs, and OpenSSL if liboqs uses it,
+// hold for the calling OS thread, with OQS_thread_stop(). It is meant for
+// goroutines locked to their thread with runtime.LockOSThread, which the Go
+// runtime terminates when they exit, see RunOnThread.
+func ThreadStop() {
+	threadStop()
+}
+
+// RunOnThread runs fn on a new goroutine locked to its OS thread, and waits for
+// it. The per-thread resources of liboqs are then released with ThreadStop,
+// and the thread terminates, hence short-lived work that would otherwise leave
+// per-thread state behind, e.g. in OpenSSL, does not leak.
+func RunOnThread(fn func()) {
+	done := make(chan struct{})
+	go func() {
+		runtime.LockOSThread()
+		// The thread terminates with the goroutine, as it stays locked
+		defer close(done)
+		defer ThreadStop()
+		fn()
+	}()
+	<-done
+}
+
+// beginOp marks the start of an operation calling into liboqs, which Shutdown
+// waits for, and initializes liboqs on first use. A nil error must be matched
+// with a call to endOp.
+func beginOp() error {
+	for {
+		activeOps.Add(1)
+		switch lifecycleState.Load() {
+		case stateInitialized:
+			return nil
+		case stateShutdown:
+			endOp()
+			return ErrShutdown
+		}
+		endOp()
+		if err := initialize(true); err != nil {
+			return err
+		}
+	}
+}
+
+// endOp marks the end of an operation started with beginOp, and wakes up
+// Shutdown if it was the last one in progress.
+func endOp() {
+	if activeOps.Add(-1) == 0 && lifecycleState.Load() == stateShutdown {
+		opsMu.Lock()
+		opsDone.Broadcast()
+		opsMu.Unlock()
+	}
+}
+
+/**************** END Lifecycle ****************/
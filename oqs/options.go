@@ -0,0 +1,70 @@
+package oqs
+
+import "io"
+
+/**************** Options ****************/
+
+// Option configures a KeyEncapsulation or a Signature. Options are passed to
+// KeyEncapsulation.Init or Signature.Init, and remain in effect until the
+// object is cleaned with KeyEncapsulation.Clean or Signature.Clean.
+type Option func(*options)
+
+// options holds the configuration collected from a list of Option values.
+type options struct {
+	strict          bool
+	rand            io.Reader
+	parallelism     int
+	pairwise        bool
+	verifyAfterSign bool
+	portable        bool
+	aead            uint8 // AEAD of Seal, see WithAEAD (Go 1.24 or later)
+}
+
+// newOptions applies opts in order and returns the resulting configuration.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&o)
+		}
+	}
+	return o
+}
+
+// WithStrictValidation enables strict mode. In strict mode, public keys,
+// ciphertexts and signatures are checked with the corresponding
+// ValidatePublicKey, ValidateCiphertext and ValidateSignatureEncoding methods
+// before being handed over to liboqs, and malformed inputs are rejected with
+// an error.
+func WithStrictValidation() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithRandomReader makes a KeyEncapsulation or a Signature read the randomness
+// of its key generation, encapsulation and hedged signing from r, instead of
+// the process-wide RNG algorithm selected by RandomBytesSwitchAlgorithm or
+// RandomBytesCustomAlgorithm. Objects with different readers can be used
+// concurrently from different goroutines. The reads from r are serialized, so
+// that the copies of an object and its abandoned context operations can share
+// it. If r fails to provide the requested bytes, the operation returns an
+// error and its results are discarded. The bytes read from r are subject to the
+// health tests, see EnableRandomHealthTests.
+//
+// With the liboqs backend, per-object readers route the liboqs RNG through a
+// Go callback, which serves the objects without a reader from the system RNG
+// or the custom RNG algorithm, hence the "OpenSSL" RNG algorithm can not be
+// selected while objects with a reader are alive. With the pure-Go backend,
+// ML-KEM encapsulation and hedged ML-DSA signing always draw their randomness
+// from crypto/rand, hence r only derandomizes key generation.
+func WithRandomReader(r io.Reader) Option {
+	return func(o *options) {
+		o.rand = nil
+		if r != nil {
+			o.rand = healthTestedReader{r: r}
+		}
+	}
+}
+
+/**************** END Options ****************/
@@ -1,32 +1,30 @@
 // Package oqs provides a GO wrapper for the C liboqs quantum-resistant library.
 package oqs // import "github.com/open-quantum-safe/liboqs-go/oqs"
 
-/*
-#cgo pkg-config: liboqs-go
-#include <oqs/oqs.h>
-typedef void (*rand_algorithm_ptr)(uint8_t*, size_t);
-void randAlgorithmPtr_cgo(uint8_t*, size_t);
-*/
-import "C"
-
 import (
 	"errors"
 	"fmt"
-	"unsafe"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /**************** Misc functions ****************/
 
-// LiboqsVersion retrieves the underlying liboqs version string.
+// LiboqsVersion retrieves the underlying liboqs version string. The pure-Go
+// backend does not use liboqs, and returns an empty string. See ParseVersion
+// and Compatibility.
 func LiboqsVersion() string {
-	return C.GoString(C.OQS_version())
+	return backendVersion()
 }
 
 // MemCleanse sets to zero the content of a byte slice by invoking the liboqs
 // OQS_MEM_cleanse() function. Use it to clean "hot" memory areas, such as
 // secret keys etc.
 func MemCleanse(v []byte) {
-	C.OQS_MEM_cleanse(unsafe.Pointer(&v[0]), C.size_t(len(v)))
+	memCleanse(v)
 }
 
 /**************** END Misc functions ****************/
@@ -41,13 +39,12 @@ var supportedKEMs []string
 
 // MaxNumberKEMs returns the maximum number of supported KEM algorithms.
 func MaxNumberKEMs() int {
-	return int(C.OQS_KEM_alg_count())
+	return kemAlgCount()
 }
 
 // IsKEMEnabled returns true if a KEM algorithm is enabled, and false otherwise.
 func IsKEMEnabled(algName string) bool {
-	result := C.OQS_KEM_alg_is_enabled(C.CString(algName))
-	return result != 0
+	return kemAlgIsEnabled(algName)
 }
 
 // IsKEMSupported returns true if a KEM algorithm is supported, and false
@@ -66,7 +63,7 @@ func KEMName(algID int) (string, error) {
 	if algID >= MaxNumberKEMs() {
 		return "", errors.New("algorithm ID out of range")
 	}
-	return C.GoString(C.OQS_KEM_alg_identifier(C.size_t(algID))), nil
+	return kemAlgIdentifier(algID), nil
 }
 
 // SupportedKEMs returns the list of supported KEM algorithms.
@@ -79,9 +76,14 @@ func EnabledKEMs() []string {
 	return enabledKEMs
 }
 
-// Initializes liboqs and the lists enabledKEMs and supportedKEMs.
+// Initializes the lists enabledKEMs and supportedKEMs.
 func init() {
-	C.OQS_init()
+	initKEMs()
+}
+
+// initKEMs (re)initializes the lists enabledKEMs and supportedKEMs.
+func initKEMs() {
+	enabledKEMs, supportedKEMs = nil, nil
 	for i := 0; i < MaxNumberKEMs(); i++ {
 		KEMName, _ := KEMName(i)
 		supportedKEMs = append(supportedKEMs, KEMName)
@@ -131,14 +133,17 @@ func (kemDetails KeyEncapsulationDetails) String() string {
 
 // KeyEncapsulation defines the KEM main data structure.
 type KeyEncapsulation struct {
-	kem        *C.OQS_KEM
+	noCopy     noCopy
+	handle     *handle[kemBackend] // shared by the copies of the receiver
 	secretKey  []byte
 	algDetails KeyEncapsulationDetails
+	opts       options
+	publicKey  []byte // kept for Open
 }
 
 // String converts the KEM algorithm name to a string representation. Use this
-// method to pretty-print the KEM algorithm name, e.g. fmt.Println(client).
-func (kem KeyEncapsulation) String() string {
+// method to pretty-print the KEM algorithm name, e.g. fmt.Println(&client).
+func (kem *KeyEncapsulation) String() string {
 	return fmt.Sprintf("Key encapsulation mechanism: %s",
 		kem.algDetails.Name)
 }
@@ -146,25 +151,63 @@ func (kem KeyEncapsulation) String() string {
 // Init initializes the KEM data structure with an algorithm name and a secret
 // key. If the secret key is null, then the user must invoke the
 // KeyEncapsulation.GenerateKeyPair method to generate the pair of
-// secret key/public key.
-func (kem *KeyEncapsulation) Init(algName string, secretKey []byte) error {
+// secret key/public key. Optional behaviour, such as strict input validation,
+// can be enabled by passing one or more Option values.
+func (kem *KeyEncapsulation) Init(algName string, secretKey []byte,
+	opts ...Option,
+) (err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, algName, OperationInit, 0, 0, false, err)
+		}()
+	}
+
+	if err := libraryError(); err != nil {
+		return err
+	}
+	if err := compatibilityError(); err != nil {
+		return err
+	}
+	if err := beginOp(); err != nil {
+		return err
+	}
+	defer endOp()
+
+	algName = resolveAlias(kemAliases, algName, IsKEMEnabled)
 	if !IsKEMEnabled(algName) {
 		// perhaps it's supported
 		if IsKEMSupported(algName) {
 			return errors.New(`"` + algName + `" KEM is not enabled by OQS`)
 		}
-		return errors.New(`"` + algName + `" KEM is not supported by OQS`)
+		return errors.New(`"` + algName + `" KEM is not supported by OQS` +
+			aliasHint(kemAliases, algName))
+	}
+	o := newOptions(opts)
+	backend, err := newKEMBackend(algName, o.rand)
+	if err != nil {
+		return err
+	}
+	if err := checkPortable(algName, o); err != nil {
+		backend.free()
+		return err
+	}
+	if err := enforcePolicy(algName, "KEM", func(p *Policy) error {
+		return p.CheckKEM(backend.details())
+	}); err != nil {
+		backend.free()
+		return err
+	}
+	if selfTestsEnabled.Load() {
+		if err := SelfTestKEM(algName); err != nil {
+			backend.free()
+			return err
+		}
 	}
-	kem.kem = C.OQS_KEM_new(C.CString(algName))
+	kem.handle = newHandle(backend)
 	kem.secretKey = secretKey
-	kem.algDetails.Name = C.GoString(kem.kem.method_name)
-	kem.algDetails.Version = C.GoString(kem.kem.alg_version)
-	kem.algDetails.ClaimedNISTLevel = int(kem.kem.claimed_nist_level)
-	kem.algDetails.IsINDCCA = bool(kem.kem.ind_cca)
-	kem.algDetails.LengthPublicKey = int(kem.kem.length_public_key)
-	kem.algDetails.LengthSecretKey = int(kem.kem.length_secret_key)
-	kem.algDetails.LengthCiphertext = int(kem.kem.length_ciphertext)
-	kem.algDetails.LengthSharedSecret = int(kem.kem.length_shared_secret)
+	kem.opts = o
+	kem.algDetails = backend.details()
 	return nil
 }
 
@@ -176,22 +219,80 @@ func (kem *KeyEncapsulation) Details() KeyEncapsulationDetails {
 // GenerateKeyPair generates a pair of secret key/public key and returns the
 // public key. The secret key is stored inside the kem receiver. The secret key
 // is not directly accessible, unless one exports it with
-// KeyEncapsulation.ExportSecretKey method.
+// KeyEncapsulation.ExportSecretKey method. A *HealthTestError is returned while
+// a health test failure is latched, see EnableRandomHealthTests.
 func (kem *KeyEncapsulation) GenerateKeyPair() ([]byte, error) {
 	publicKey := make([]byte, kem.algDetails.LengthPublicKey)
-	kem.secretKey = make([]byte, kem.algDetails.LengthSecretKey)
+	// Never overwrite a previously exported secret key, nor the public key of
+	// a snapshot taken by the context variants
+	kem.secretKey = nil
+	kem.publicKey = nil
+	if err := kem.GenerateKeyPairInto(publicKey); err != nil {
+		return nil, err
+	}
 
-	rv := C.OQS_KEM_keypair(
-		kem.kem,
-		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
-		(*C.uint8_t)(unsafe.Pointer(&kem.secretKey[0])),
-	)
+	return publicKey, nil
+}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, errors.New("can not generate keypair")
+// GenerateKeyPairInto works like KeyEncapsulation.GenerateKeyPair, but writes
+// the public key into publicKey, which must be
+// KeyEncapsulationDetails.LengthPublicKey bytes long. The secret key buffer of
+// the kem receiver is reused, hence GenerateKeyPairInto overwrites the secret
+// key passed to KeyEncapsulation.Init or returned by
+// KeyEncapsulation.ExportSecretKey.
+func (kem *KeyEncapsulation) GenerateKeyPairInto(publicKey []byte) (
+	err error,
+) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, kem.algDetails.Name, OperationKeypair, 0,
+				len(publicKey), false, err)
+		}()
 	}
 
-	return publicKey, nil
+	backend, err := kem.handle.acquire()
+	if err != nil {
+		return err
+	}
+	defer kem.handle.release()
+
+	if len(publicKey) != kem.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+
+	if err := RandomHealthTestError(); err != nil {
+		return err
+	}
+
+	if len(kem.secretKey) != kem.algDetails.LengthSecretKey {
+		kem.secretKey = make([]byte, kem.algDetails.LengthSecretKey)
+	} else {
+		// Abandoned operations may still read the secret key
+		kem.waitPending()
+	}
+
+	if err := backend.keypair(publicKey, kem.secretKey); err != nil {
+		return err
+	}
+
+	// The health tests may have failed on the randomness of the key pair
+	if err := RandomHealthTestError(); err != nil {
+		MemCleanse(kem.secretKey)
+		return err
+	}
+
+	if kem.opts.pairwise || selfTestsEnabled.Load() {
+		err := pairwiseConsistencyKEM(backend, publicKey, kem.secretKey)
+		if err != nil {
+			MemCleanse(kem.secretKey)
+			return err
+		}
+	}
+
+	kem.publicKey = append(kem.publicKey[:0], publicKey...)
+
+	return nil
 }
 
 // ExportSecretKey exports the corresponding secret key from the kem receiver.
@@ -199,67 +300,155 @@ func (kem *KeyEncapsulation) ExportSecretKey() []byte {
 	return kem.secretKey
 }
 
+// ImportPublicKey imports the public key corresponding to the secret key of the
+// kem receiver, which KeyEncapsulation.Open needs when the secret key is passed
+// to KeyEncapsulation.Init rather than generated with
+// KeyEncapsulation.GenerateKeyPair.
+func (kem *KeyEncapsulation) ImportPublicKey(publicKey []byte) error {
+	if len(publicKey) != kem.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+
+	kem.publicKey = append([]byte(nil), publicKey...)
+
+	return nil
+}
+
 // EncapSecret encapsulates a secret using a public key and returns the
-// corresponding ciphertext and shared secret.
+// corresponding ciphertext and shared secret. In strict mode, the public key is
+// first checked with KeyEncapsulation.ValidatePublicKey.
 func (kem *KeyEncapsulation) EncapSecret(publicKey []byte) (ciphertext,
 	sharedSecret []byte, err error,
 ) {
+	ciphertext = make([]byte, kem.algDetails.LengthCiphertext)
+	sharedSecret = make([]byte, kem.algDetails.LengthSharedSecret)
+	if err := kem.EncapSecretTo(ciphertext, sharedSecret, publicKey); err != nil {
+		return nil, nil, err
+	}
+
+	return ciphertext, sharedSecret, nil
+}
+
+// EncapSecretTo works like KeyEncapsulation.EncapSecret, but writes the
+// ciphertext and the shared secret into the caller's buffers, which must be
+// KeyEncapsulationDetails.LengthCiphertext and
+// KeyEncapsulationDetails.LengthSharedSecret bytes long, respectively.
+func (kem *KeyEncapsulation) EncapSecretTo(ciphertext []byte,
+	sharedSecret []byte, publicKey []byte,
+) (err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, kem.algDetails.Name, OperationEncaps,
+				len(publicKey), len(ciphertext), false, err)
+		}()
+	}
+
+	backend, err := kem.handle.acquire()
+	if err != nil {
+		return err
+	}
+	defer kem.handle.release()
+
 	if len(publicKey) != kem.algDetails.LengthPublicKey {
-		return nil, nil, errors.New("incorrect public key length")
+		return errors.New("incorrect public key length")
 	}
 
-	ciphertext = make([]byte, kem.algDetails.LengthCiphertext)
-	sharedSecret = make([]byte, kem.algDetails.LengthSharedSecret)
+	if len(ciphertext) != kem.algDetails.LengthCiphertext {
+		return errors.New("incorrect ciphertext length")
+	}
+
+	if len(sharedSecret) != kem.algDetails.LengthSharedSecret {
+		return errors.New("incorrect shared secret length")
+	}
 
-	rv := C.OQS_KEM_encaps(
-		kem.kem,
-		(*C.uint8_t)(unsafe.Pointer(&ciphertext[0])),
-		(*C.uint8_t)(unsafe.Pointer(&sharedSecret[0])),
-		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
-	)
+	if kem.opts.strict {
+		if err := kem.ValidatePublicKey(publicKey); err != nil {
+			return err
+		}
+	}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, nil, errors.New("can not encapsulate secret")
+	if err := RandomHealthTestError(); err != nil {
+		return err
 	}
 
-	return ciphertext, sharedSecret, nil
+	if err := backend.encaps(ciphertext, sharedSecret, publicKey); err != nil {
+		return err
+	}
+
+	if err := RandomHealthTestError(); err != nil {
+		MemCleanse(sharedSecret)
+		return err
+	}
+
+	return nil
 }
 
 // DecapSecret decapsulates a ciphertexts and returns the corresponding shared
-// secret.
+// secret. In strict mode, the ciphertext is first checked with
+// KeyEncapsulation.ValidateCiphertext.
 func (kem *KeyEncapsulation) DecapSecret(ciphertext []byte) ([]byte, error) {
+	sharedSecret := make([]byte, kem.algDetails.LengthSharedSecret)
+	if err := kem.DecapSecretTo(sharedSecret, ciphertext); err != nil {
+		return nil, err
+	}
+
+	return sharedSecret, nil
+}
+
+// DecapSecretTo works like KeyEncapsulation.DecapSecret, but writes the shared
+// secret into sharedSecret, which must be
+// KeyEncapsulationDetails.LengthSharedSecret bytes long.
+func (kem *KeyEncapsulation) DecapSecretTo(sharedSecret []byte,
+	ciphertext []byte,
+) (err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, kem.algDetails.Name, OperationDecaps,
+				len(ciphertext), len(sharedSecret), false, err)
+		}()
+	}
+
+	backend, err := kem.handle.acquire()
+	if err != nil {
+		return err
+	}
+	defer kem.handle.release()
+
 	if len(ciphertext) != kem.algDetails.LengthCiphertext {
-		return nil, errors.New("incorrect ciphertext length")
+		return errors.New("incorrect ciphertext length")
 	}
 
-	if len(kem.secretKey) != kem.algDetails.LengthSecretKey {
-		return nil, errors.New("incorrect secret key length, make sure you " +
-			"specify one in Init() or run GenerateKeyPair()")
+	if len(sharedSecret) != kem.algDetails.LengthSharedSecret {
+		return errors.New("incorrect shared secret length")
 	}
 
-	sharedSecret := make([]byte, kem.algDetails.LengthSharedSecret)
-	rv := C.OQS_KEM_decaps(
-		kem.kem,
-		(*C.uint8_t)(unsafe.Pointer(&sharedSecret[0])),
-		(*C.uchar)(unsafe.Pointer(&ciphertext[0])),
-		(*C.uint8_t)(unsafe.Pointer(&kem.secretKey[0])),
-	)
+	if kem.opts.strict {
+		if err := kem.ValidateCiphertext(ciphertext); err != nil {
+			return err
+		}
+	}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, errors.New("can not decapsulate secret")
+	if len(kem.secretKey) != kem.algDetails.LengthSecretKey {
+		return errors.New("incorrect secret key length, make sure you " +
+			"specify one in Init() or run GenerateKeyPair()")
 	}
 
-	return sharedSecret, nil
+	return backend.decaps(sharedSecret, ciphertext, kem.secretKey)
 }
 
 // Clean zeroes-in the stored secret key and resets the kem receiver. One can
 // reuse the KEM by re-initializing it with the KeyEncapsulation.Init method.
 func (kem *KeyEncapsulation) Clean() {
+	kem.waitPending()
 	if len(kem.secretKey) > 0 {
 		MemCleanse(kem.secretKey)
 	}
-	C.OQS_KEM_free(kem.kem)
-	*kem = KeyEncapsulation{}
+	// The handle is kept to report the use of the cleaned receiver
+	h := kem.handle
+	h.close()
+	*kem = KeyEncapsulation{handle: h}
 }
 
 /**************** END KeyEncapsulation ****************/
@@ -274,14 +463,13 @@ var supportedSigs []string
 
 // MaxNumberSigs returns the maximum number of supported signature algorithms.
 func MaxNumberSigs() int {
-	return int(C.OQS_SIG_alg_count())
+	return sigAlgCount()
 }
 
 // IsSigEnabled returns true if a signature algorithm is enabled, and false
 // otherwise.
 func IsSigEnabled(algName string) bool {
-	result := C.OQS_SIG_alg_is_enabled(C.CString(algName))
-	return result != 0
+	return sigAlgIsEnabled(algName)
 }
 
 // IsSigSupported returns true if a signature algorithm is supported, and false
@@ -301,7 +489,7 @@ func SigName(algID int) (string, error) {
 	if algID >= MaxNumberSigs() {
 		return "", errors.New("algorithm ID out of range")
 	}
-	return C.GoString(C.OQS_SIG_alg_identifier(C.size_t(algID))), nil
+	return sigAlgIdentifier(algID), nil
 }
 
 // SupportedSigs returns the list of supported signature algorithms.
@@ -316,6 +504,12 @@ func EnabledSigs() []string {
 
 // Initializes the lists enabledSigs and supportedSigs.
 func init() {
+	initSigs()
+}
+
+// initSigs (re)initializes the lists enabledSigs and supportedSigs.
+func initSigs() {
+	enabledSigs, supportedSigs = nil, nil
 	for i := 0; i < MaxNumberSigs(); i++ {
 		sigName, _ := SigName(i)
 		supportedSigs = append(supportedSigs, sigName)
@@ -329,6 +523,10 @@ func init() {
 
 /**************** Signature ****************/
 
+// MaxLengthContext is the maximum length of a context string, as specified in
+// FIPS 204 and FIPS 205.
+const MaxLengthContext = 255
+
 // SignatureDetails defines the signature algorithm details.
 type SignatureDetails struct {
 	Name               string
@@ -365,15 +563,18 @@ func (sigDetails SignatureDetails) String() string {
 
 // Signature defines the signature main data structure.
 type Signature struct {
-	sig        *C.OQS_SIG
+	noCopy     noCopy
+	handle     *handle[sigBackend] // shared by the copies of the receiver
 	secretKey  []byte
 	algDetails SignatureDetails
+	opts       options
+	publicKey  []byte // kept for WithVerifyAfterSign
 }
 
 // String converts the signature algorithm name to a string representation.
 // Use this method to pretty-print the signature algorithm name, e.g.
-// fmt.Println(signer).
-func (sig Signature) String() string {
+// fmt.Println(&signer).
+func (sig *Signature) String() string {
 	return fmt.Sprintf("Signature mechanism: %s",
 		sig.algDetails.Name)
 }
@@ -381,8 +582,30 @@ func (sig Signature) String() string {
 // Init initializes the signature data structure with an algorithm name and a
 // secret key. If the secret key is null, then the user must invoke the
 // Signature.GenerateKeyPair method to generate the pair of secret key/public
-// key.
-func (sig *Signature) Init(algName string, secretKey []byte) error {
+// key. Optional behaviour, such as strict input validation, can be enabled by
+// passing one or more Option values.
+func (sig *Signature) Init(algName string, secretKey []byte,
+	opts ...Option,
+) (err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, algName, OperationInit, 0, 0, false, err)
+		}()
+	}
+
+	if err := libraryError(); err != nil {
+		return err
+	}
+	if err := compatibilityError(); err != nil {
+		return err
+	}
+	if err := beginOp(); err != nil {
+		return err
+	}
+	defer endOp()
+
+	algName = resolveAlias(sigAliases, algName, IsSigEnabled)
 	if !IsSigEnabled(algName) {
 		// perhaps it's supported
 		if IsSigSupported(algName) {
@@ -390,19 +613,35 @@ func (sig *Signature) Init(algName string, secretKey []byte) error {
 				`" signature mechanism is not enabled by OQS`)
 		}
 		return errors.New(`"` + algName +
-			`" signature mechanism is not supported by OQS`)
+			`" signature mechanism is not supported by OQS` +
+			aliasHint(sigAliases, algName))
 
 	}
-	sig.sig = C.OQS_SIG_new(C.CString(algName))
+	o := newOptions(opts)
+	backend, err := newSigBackend(algName, o.rand)
+	if err != nil {
+		return err
+	}
+	if err := checkPortable(algName, o); err != nil {
+		backend.free()
+		return err
+	}
+	if err := enforcePolicy(algName, "signature", func(p *Policy) error {
+		return p.CheckSignature(backend.details())
+	}); err != nil {
+		backend.free()
+		return err
+	}
+	if selfTestsEnabled.Load() {
+		if err := SelfTestSig(algName); err != nil {
+			backend.free()
+			return err
+		}
+	}
+	sig.handle = newHandle(backend)
 	sig.secretKey = secretKey
-	sig.algDetails.Name = C.GoString(sig.sig.method_name)
-	sig.algDetails.Version = C.GoString(sig.sig.alg_version)
-	sig.algDetails.ClaimedNISTLevel = int(sig.sig.claimed_nist_level)
-	sig.algDetails.IsEUFCMA = bool(sig.sig.euf_cma)
-	sig.algDetails.SigWithCtxSupport = bool(sig.sig.sig_with_ctx_support)
-	sig.algDetails.LengthPublicKey = int(sig.sig.length_public_key)
-	sig.algDetails.LengthSecretKey = int(sig.sig.length_secret_key)
-	sig.algDetails.MaxLengthSignature = int(sig.sig.length_signature)
+	sig.opts = o
+	sig.algDetails = backend.details()
 
 	return nil
 }
@@ -415,22 +654,79 @@ func (sig *Signature) Details() SignatureDetails {
 // GenerateKeyPair generates a pair of secret key/public key and returns the
 // public key. The secret key is stored inside the sig receiver. The secret key
 // is not directly accessible, unless one exports it with
-// Signature.ExportSecretKey method.
+// Signature.ExportSecretKey method. A *HealthTestError is returned while
+// a health test failure is latched, see EnableRandomHealthTests.
 func (sig *Signature) GenerateKeyPair() ([]byte, error) {
 	publicKey := make([]byte, sig.algDetails.LengthPublicKey)
-	sig.secretKey = make([]byte, sig.algDetails.LengthSecretKey)
+	// Never overwrite a previously exported secret key, nor the public key of
+	// a snapshot taken by the context variants
+	sig.secretKey = nil
+	sig.publicKey = nil
+	if err := sig.GenerateKeyPairInto(publicKey); err != nil {
+		return nil, err
+	}
+
+	return publicKey, nil
+}
 
-	rv := C.OQS_SIG_keypair(
-		sig.sig,
-		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
-		(*C.uint8_t)(unsafe.Pointer(&sig.secretKey[0])),
-	)
+// GenerateKeyPairInto works like Signature.GenerateKeyPair, but writes the
+// public key into publicKey, which must be SignatureDetails.LengthPublicKey
+// bytes long. The secret key buffer of the sig receiver is reused, hence
+// GenerateKeyPairInto overwrites the secret key passed to Signature.Init or
+// returned by Signature.ExportSecretKey.
+func (sig *Signature) GenerateKeyPairInto(publicKey []byte) (err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationKeypair, 0,
+				len(publicKey), false, err)
+		}()
+	}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, errors.New("can not generate keypair")
+	backend, err := sig.handle.acquire()
+	if err != nil {
+		return err
 	}
+	defer sig.handle.release()
 
-	return publicKey, nil
+	if len(publicKey) != sig.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+
+	if err := RandomHealthTestError(); err != nil {
+		return err
+	}
+
+	if len(sig.secretKey) != sig.algDetails.LengthSecretKey {
+		sig.secretKey = make([]byte, sig.algDetails.LengthSecretKey)
+	} else {
+		// Abandoned operations may still read the secret key
+		sig.waitPending()
+	}
+
+	if err := backend.keypair(publicKey, sig.secretKey); err != nil {
+		return err
+	}
+
+	// The health tests may have failed on the randomness of the key pair
+	if err := RandomHealthTestError(); err != nil {
+		MemCleanse(sig.secretKey)
+		return err
+	}
+
+	if sig.opts.pairwise || selfTestsEnabled.Load() {
+		err := pairwiseConsistencySig(backend, publicKey, sig.secretKey)
+		if err != nil {
+			MemCleanse(sig.secretKey)
+			return err
+		}
+	}
+
+	if sig.opts.verifyAfterSign {
+		sig.publicKey = append(sig.publicKey[:0], publicKey...)
+	}
+
+	return nil
 }
 
 // ExportSecretKey exports the corresponding secret key from the sig receiver.
@@ -438,68 +734,193 @@ func (sig *Signature) ExportSecretKey() []byte {
 	return sig.secretKey
 }
 
-// Sign signs a message and returns the corresponding signature.
+// Sign signs a message and returns the corresponding signature. The message
+// may be empty.
 func (sig *Signature) Sign(message []byte) ([]byte, error) {
-	if len(sig.secretKey) != sig.algDetails.LengthSecretKey {
-		return nil, errors.New("incorrect secret key length, make sure you " +
-			"specify one in Init() or run GenerateKeyPair()")
+	signature, err := sig.SignAppend(nil, message)
+	if err != nil {
+		return nil, err
 	}
 
-	signature := make([]byte, sig.algDetails.MaxLengthSignature)
-	var lenSig uint64
-	rv := C.OQS_SIG_sign(
-		sig.sig,
-		(*C.uint8_t)(unsafe.Pointer(&signature[0])),
-		(*C.size_t)(unsafe.Pointer(&lenSig)),
-		(*C.uint8_t)(unsafe.Pointer(&message[0])),
-		C.size_t(len(message)),
-		(*C.uint8_t)(unsafe.Pointer(&sig.secretKey[0])),
-	)
+	return exactSignature(signature), nil
+}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, errors.New("can not sign message")
+// SignAppend signs a message and appends the signature to dst, returning the
+// updated slice. dst only grows if its spare capacity is less than
+// SignatureDetails.MaxLengthSignature, hence reusing the returned slice, e.g.
+// as buf = sig.SignAppend(buf[:0], message), avoids allocations.
+func (sig *Signature) SignAppend(dst []byte, message []byte) (out []byte,
+	err error,
+) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationSign, len(message),
+				len(out)-len(dst), false, err)
+		}()
 	}
 
-	return signature[:lenSig], nil
+	return sig.signAppend(dst, message, nil, signHedged)
 }
 
 // Sign signs a message with context string and returns the corresponding
-// signature.
-func (sig *Signature) SignWithCtxStr(message []byte, context []byte) ([]byte, error) {
-	if len(context) > 0 && !sig.algDetails.SigWithCtxSupport {
-		return nil, errors.New("can not sign message with context string")
+// signature. Both the message and the context string may be empty or nil; a
+// context string can not be longer than MaxLengthContext bytes.
+func (sig *Signature) SignWithCtxStr(message []byte, context []byte) (
+	signature []byte, err error,
+) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationSign, len(message),
+				len(signature), false, err)
+		}()
+	}
+
+	signature, err = sig.signAppend(nil, message, context, signHedgedWithCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return exactSignature(signature), nil
+}
+
+// SignDeterministic signs a message with the deterministic variant of ML-DSA,
+// see FIPS 204, Section 3.4, and returns the corresponding signature. Signing
+// the same message with the same secret key always yields the same signature.
+// An error is returned for every other signature algorithm. With the liboqs
+// backend, the zero randomness of the deterministic variant is routed through
+// the Go RNG callback, like the readers of WithRandomReader, hence an error is
+// returned as well while the "OpenSSL" RNG algorithm is selected, see
+// RandomBytesSwitchAlgorithm.
+func (sig *Signature) SignDeterministic(message []byte) ([]byte, error) {
+	return sig.SignWithCtxDeterministic(message, nil)
+}
+
+// SignWithCtxDeterministic signs a message with context string with the
+// deterministic variant of ML-DSA, and returns the corresponding signature.
+// See Signature.SignDeterministic.
+func (sig *Signature) SignWithCtxDeterministic(message []byte,
+	context []byte,
+) (signature []byte, err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationSign, len(message),
+				len(signature), false, err)
+		}()
+	}
+
+	signature, err = sig.signAppend(nil, message, context, signDeterministic)
+	if err != nil {
+		return nil, err
+	}
+
+	return exactSignature(signature), nil
+}
+
+// signMode selects the signing variant of signAppend.
+type signMode int
+
+const (
+	signHedged        signMode = iota // Sign, without a context string
+	signHedgedWithCtx                 // SignWithCtxStr
+	signDeterministic                 // SignWithCtxDeterministic
+)
+
+// signAppend signs a message with context string, with the variant selected by
+// mode, and appends the signature to dst. On error, it returns dst unchanged.
+func (sig *Signature) signAppend(dst, message, context []byte,
+	mode signMode,
+) ([]byte, error) {
+	backend, err := sig.handle.acquire()
+	if err != nil {
+		return dst, err
+	}
+	defer sig.handle.release()
+
+	switch mode {
+	case signHedgedWithCtx:
+		if len(context) > 0 && !sig.algDetails.SigWithCtxSupport {
+			return dst, errors.New("can not sign message with context " +
+				"string")
+		}
+	case signDeterministic:
+		if _, ok := mldsaParamSets[sig.algDetails.Name]; !ok {
+			return dst, errors.New(`"` + sig.algDetails.Name +
+				`" does not support deterministic signing`)
+		}
+	}
+
+	if len(context) > MaxLengthContext {
+		return dst, errors.New("context string too long")
 	}
 
 	if len(sig.secretKey) != sig.algDetails.LengthSecretKey {
-		return nil, errors.New("incorrect secret key length, make sure you " +
+		return dst, errors.New("incorrect secret key length, make sure you " +
 			"specify one in Init() or run GenerateKeyPair()")
 	}
 
-	signature := make([]byte, sig.algDetails.MaxLengthSignature)
-	var lenSig uint64
-	rv := C.OQS_SIG_sign_with_ctx_str(
-		sig.sig,
-		(*C.uint8_t)(unsafe.Pointer(&signature[0])),
-		(*C.size_t)(unsafe.Pointer(&lenSig)),
-		(*C.uint8_t)(unsafe.Pointer(&message[0])),
-		C.size_t(len(message)),
-		(*C.uint8_t)(unsafe.Pointer(&context[0])),
-		C.size_t(len(context)),
-		(*C.uint8_t)(unsafe.Pointer(&sig.secretKey[0])),
-	)
+	if err := sig.checkVerifyAfterSign(); err != nil {
+		return dst, err
+	}
+
+	dst = slices.Grow(dst, sig.algDetails.MaxLengthSignature)
+	signature := dst[len(dst) : len(dst)+sig.algDetails.MaxLengthSignature]
+	var lenSig int
+	switch mode {
+	case signHedged:
+		lenSig, err = backend.sign(signature, message, sig.secretKey)
+	case signHedgedWithCtx:
+		lenSig, err = backend.signWithCtxStr(signature, message, context,
+			sig.secretKey)
+	case signDeterministic:
+		lenSig, err = backend.signDeterministic(signature, message, context,
+			sig.secretKey)
+	}
+	if err != nil {
+		return dst, err
+	}
 
-	if rv != C.OQS_SUCCESS {
-		return nil, errors.New("can not sign message")
+	if err := sig.verifyAfterSign(backend, signature[:lenSig], message,
+		context); err != nil {
+		return dst, err
 	}
 
-	return signature[:lenSig], nil
+	return dst[:len(dst)+lenSig], nil
+}
+
+// exactSignature returns signature in a buffer of its own length, so that the
+// unused tail of a maximum-length signature buffer, e.g. for Falcon, is not
+// kept alive by the caller.
+func exactSignature(signature []byte) []byte {
+	if len(signature) == cap(signature) {
+		return signature
+	}
+	return append(make([]byte, 0, len(signature)), signature...)
 }
 
 // Verify verifies the validity of a signed message, returning true if the
-// signature is valid, and false otherwise.
+// signature is valid, and false otherwise. In strict mode, the public key and
+// the signature are first checked with Signature.ValidatePublicKey and
+// Signature.ValidateSignatureEncoding, respectively.
 func (sig *Signature) Verify(message []byte, signature []byte,
 	publicKey []byte,
-) (bool, error) {
+) (isValid bool, err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationVerify, len(message),
+				len(signature), isValid, err)
+		}()
+	}
+
+	backend, err := sig.handle.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer sig.handle.release()
+
 	if len(publicKey) != sig.algDetails.LengthPublicKey {
 		return false, errors.New("incorrect public key length")
 	}
@@ -508,34 +929,44 @@ func (sig *Signature) Verify(message []byte, signature []byte,
 		return false, errors.New("incorrect signature size")
 	}
 
-	rv := C.OQS_SIG_verify(
-		sig.sig,
-		(*C.uint8_t)(unsafe.Pointer(&message[0])),
-		C.size_t(len(message)),
-		(*C.uint8_t)(unsafe.Pointer(&signature[0])),
-		C.size_t(len(signature)),
-		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
-	)
-
-	if rv != C.OQS_SUCCESS {
-		return false, nil
+	if err := sig.validateStrict(signature, publicKey); err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return backend.verify(message, signature, publicKey), nil
 }
 
 // Verify verifies the validity of a signed message with context string,
-// returning true if the signature is valid, and false otherwise.
+// returning true if the signature is valid, and false otherwise. Strict mode
+// applies the same checks as in Signature.Verify.
 func (sig *Signature) VerifyWithCtxStr(
 	message []byte,
 	signature []byte,
 	context []byte,
 	publicKey []byte,
-) (bool, error) {
+) (isValid bool, err error) {
+	if h := currentHook(); h != nil {
+		start := time.Now()
+		defer func() {
+			report(h, start, sig.algDetails.Name, OperationVerify, len(message),
+				len(signature), isValid, err)
+		}()
+	}
+
+	backend, err := sig.handle.acquire()
+	if err != nil {
+		return false, err
+	}
+	defer sig.handle.release()
+
 	if len(context) > 0 && !sig.algDetails.SigWithCtxSupport {
 		return false, errors.New("can not sign message with context string")
 	}
 
+	if len(context) > MaxLengthContext {
+		return false, errors.New("context string too long")
+	}
+
 	if len(publicKey) != sig.algDetails.LengthPublicKey {
 		return false, errors.New("incorrect public key length")
 	}
@@ -544,34 +975,39 @@ func (sig *Signature) VerifyWithCtxStr(
 		return false, errors.New("incorrect signature size")
 	}
 
-	rv := C.OQS_SIG_verify_with_ctx_str(
-		sig.sig,
-		(*C.uint8_t)(
-			unsafe.Pointer(&message[0]),
-		),
-		C.size_t(len(message)),
-		(*C.uint8_t)(unsafe.Pointer(&signature[0])),
-		C.size_t(len(signature)),
-		(*C.uint8_t)(unsafe.Pointer(&context[0])),
-		C.size_t(len(context)),
-		(*C.uint8_t)(unsafe.Pointer(&publicKey[0])),
-	)
-
-	if rv != C.OQS_SUCCESS {
-		return false, nil
+	if err := sig.validateStrict(signature, publicKey); err != nil {
+		return false, err
 	}
 
-	return true, nil
+	return backend.verifyWithCtxStr(message, signature, context, publicKey),
+		nil
 }
 
 // Clean zeroes-in the stored secret key and resets the sig receiver. One can
 // reuse the signature by re-initializing it with the Signature.Init method.
 func (sig *Signature) Clean() {
+	sig.waitPending()
 	if len(sig.secretKey) > 0 {
 		MemCleanse(sig.secretKey)
 	}
-	C.OQS_SIG_free(sig.sig)
-	*sig = Signature{}
+	// The handle is kept to report the use of the cleaned receiver
+	h := sig.handle
+	h.close()
+	*sig = Signature{handle: h}
+}
+
+// ImportPublicKey imports the public key corresponding to the secret key of the
+// sig receiver, which WithVerifyAfterSign needs to verify the signatures when
+// the secret key is passed to Signature.Init or Signature.ImportSecretKey
+// rather than generated with Signature.GenerateKeyPair.
+func (sig *Signature) ImportPublicKey(publicKey []byte) error {
+	if len(publicKey) != sig.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+
+	sig.publicKey = append([]byte(nil), publicKey...)
+
+	return nil
 }
 
 // ImportSecretKey imports an existing secret key for use with this signature object
@@ -595,57 +1031,124 @@ func (sig *Signature) ImportSecretKey(secretKey []byte) error {
 /**************** Callbacks ****************/
 
 // randAlgorithmPtrCallback is a global RNG algorithm callback set by
-// RandomBytesCustomAlgorithm.
-var randAlgorithmPtrCallback func([]byte, int)
-
-// randAlgorithmPtr is automatically invoked by RandomBytesCustomAlgorithm. When
-// invoked, the memory is provided by the caller, i.e. RandomBytes or
-// RandomBytesInPlace.
-//
-//export randAlgorithmPtr
-func randAlgorithmPtr(randomArray *C.uint8_t, bytesToRead C.size_t) {
-	// TODO optimize the copying if possible!
-	result := make([]byte, int(bytesToRead))
-	randAlgorithmPtrCallback(result, int(bytesToRead))
-	p := unsafe.Pointer(randomArray)
-	for _, v := range result {
-		*(*C.uint8_t)(p) = C.uint8_t(v)
-		p = unsafe.Pointer(uintptr(p) + 1)
+// RandomBytesCustomAlgorithm. It is written with randMu held, but read
+// atomically, since it is called from the liboqs RNG callback.
+var randAlgorithmPtrCallback atomic.Pointer[func([]byte, int)]
+
+// loadRandomCallback returns the custom RNG algorithm callback, or nil if none
+// is in use.
+func loadRandomCallback() func([]byte, int) {
+	if callback := randAlgorithmPtrCallback.Load(); callback != nil {
+		return *callback
 	}
+	return nil
 }
 
 /**************** END Callbacks ****************/
 
+// CustomRandomAlgorithm is the name reported by CurrentRandomAlgorithm while a
+// custom RNG algorithm is in use.
+const CustomRandomAlgorithm = "custom"
+
+// randMu guards the RNG algorithm state, i.e. randAlgorithm,
+// randAlgorithmPtrCallback and the backend RNG algorithm.
+var randMu sync.Mutex
+
+// randAlgorithm holds the name of the RNG algorithm in use, as reported by
+// CurrentRandomAlgorithm. It is written with randMu held, but read atomically.
+var randAlgorithm atomic.Value
+
+func init() {
+	randAlgorithm.Store("system")
+}
+
+// Reader is a global, shared instance of a cryptographically secure random
+// number generator. It implements io.Reader on top of OQS_randombytes, hence
+// reads from whichever RNG algorithm is in use, and never allocates.
+var Reader io.Reader = reader{}
+
+// reader implements Reader.
+type reader struct{}
+
+// Read fills b with random bytes. It returns len(b), nil, unless the health
+// tests of the custom RNG algorithm failed, see EnableRandomHealthTests, the
+// reader of an enclosing WithRandomSource failed, or liboqs was shut down, see
+// Shutdown.
+func (reader) Read(b []byte) (int, error) {
+	if err := RandomHealthTestError(); err != nil {
+		return 0, err
+	}
+	if err := beginOp(); err != nil {
+		return 0, err
+	}
+	defer endOp()
+	if err := randomBytes(b); err != nil {
+		return 0, err
+	}
+	if err := RandomHealthTestError(); err != nil {
+		clear(b)
+		return 0, err
+	}
+	return len(b), nil
+}
+
 // RandomBytes generates bytesToRead random bytes. This implementation uses
 // either the default RNG algorithm ("system"), or whichever algorithm has been
-// selected by RandomBytesSwitchAlgorithm.
+// selected by RandomBytesSwitchAlgorithm. It panics with ErrShutdown after
+// Shutdown, and when the reader of an enclosing WithRandomSource fails, like
+// RandomBytesInPlace; use ReadRandomBytes or Reader to handle these errors.
 func RandomBytes(bytesToRead int) []byte {
 	result := make([]byte, bytesToRead)
-	C.OQS_randombytes((*C.uint8_t)(unsafe.Pointer(&result[0])),
-		C.size_t(bytesToRead))
+	if err := randomBytesOp(result); err != nil {
+		panic(err)
+	}
 	return result
 }
 
 // RandomBytesInPlace generates bytesToRead random bytes. This implementation
 // uses either the default RNG algorithm ("system"), or whichever algorithm has
 // been selected by RandomBytesSwitchAlgorithm. If bytesToRead exceeds the size
-// of randomArray, only len(randomArray) bytes are read.
+// of randomArray, only len(randomArray) bytes are read. As it can not report
+// an error, it panics with ErrShutdown after Shutdown, or when the reader of an
+// enclosing WithRandomSource fails, rather than leaving randomArray unfilled;
+// use ReadRandomBytes to handle these errors.
 func RandomBytesInPlace(randomArray []byte, bytesToRead int) {
 	if bytesToRead > len(randomArray) {
 		bytesToRead = len(randomArray)
 	}
-	C.OQS_randombytes((*C.uint8_t)(unsafe.Pointer(&randomArray[0])),
-		C.size_t(bytesToRead))
+	if err := randomBytesOp(randomArray[:bytesToRead]); err != nil {
+		panic(err)
+	}
+}
+
+// ReadRandomBytes fills randomArray with random bytes, like
+// RandomBytesInPlace, but returns ErrShutdown after Shutdown, or the error of
+// the reader of an enclosing WithRandomSource, instead of panicking.
+func ReadRandomBytes(randomArray []byte) error {
+	return randomBytesOp(randomArray)
+}
+
+// randomBytesOp fills randomArray as an operation Shutdown waits for.
+func randomBytesOp(randomArray []byte) error {
+	if err := beginOp(); err != nil {
+		return err
+	}
+	defer endOp()
+	return randomBytes(randomArray)
 }
 
 // RandomBytesSwitchAlgorithm switches the core OQS_randombytes to use the
 // specified algorithm. Possible values are "system" and "OpenSSL".
-// See <oqs/rand.h> liboqs header for more details.
+// See <oqs/rand.h> liboqs header for more details. The pure-Go backend only
+// supports "system", which is backed by crypto/rand.
 func RandomBytesSwitchAlgorithm(algName string) error {
-	if C.OQS_randombytes_switch_algorithm(C.CString(algName)) != C.OQS_SUCCESS {
-		return errors.New("can not switch to \"" + algName + "\" algorithm")
+	if err := beginOp(); err != nil {
+		return err
 	}
-	return nil
+	defer endOp()
+	randMu.Lock()
+	defer randMu.Unlock()
+	return switchRandomAlgorithm(algName)
 }
 
 // RandomBytesCustomAlgorithm switches RandomBytes to use the given function.
@@ -656,10 +1159,76 @@ func RandomBytesCustomAlgorithm(fun func([]byte, int)) error {
 	if fun == nil {
 		return errors.New("the RNG algorithm callback can not be nil")
 	}
-	randAlgorithmPtrCallback = fun
-	C.OQS_randombytes_custom_algorithm(
-		(C.rand_algorithm_ptr)(unsafe.Pointer(C.randAlgorithmPtr_cgo)))
+	if err := beginOp(); err != nil {
+		return err
+	}
+	defer endOp()
+	randMu.Lock()
+	defer randMu.Unlock()
+	setCustomRandomAlgorithm(fun)
+	return nil
+}
+
+// CurrentRandomAlgorithm returns the name of the RNG algorithm in use, i.e.
+// "system" (the default), the name passed to the last successful
+// RandomBytesSwitchAlgorithm call, or CustomRandomAlgorithm.
+func CurrentRandomAlgorithm() string {
+	return randAlgorithm.Load().(string)
+}
+
+// WithRandomSource runs fn with the randomness of the calling goroutine,
+// including the one consumed by key generation, encapsulation and signing, read
+// from r, and returns the error of r, if any. The objects configured with
+// WithRandomReader are not affected, and the RNG algorithm in use is left
+// unchanged. If r fails to provide the requested bytes, the operations within
+// fn return an error, and the following reads from r are skipped. The bytes
+// read from r are subject to the health tests, see EnableRandomHealthTests.
+//
+// With the liboqs backend, other goroutines, including the ones started by fn,
+// are not affected either, and calls can be nested, in which case the innermost
+// reader is used. The backends without liboqs have no per-thread dispatch:
+// there, concurrent calls are serialized by a lock held until fn returns, every
+// goroutine reads its randomness from r while fn runs, and fn must not call
+// WithRandomSource again. With the pure-Go backend, encapsulation and hedged
+// signing ignore r.
+func WithRandomSource(r io.Reader, fn func()) error {
+	if r == nil {
+		return errors.New("the random source can not be nil")
+	}
+	return randomScope(healthTestedReader{r: r}, fn)
+}
+
+// randomDispatchError is returned when a feature reading its randomness from a
+// reader, i.e. a per-object reader, WithRandomSource or deterministic signing,
+// can not be set up because the RNG algorithm in use, e.g. "OpenSSL" with the
+// liboqs backend, can not be combined with it.
+type randomDispatchError struct {
+	feature string
+	algName string
+}
+
+func (e *randomDispatchError) Error() string {
+	return e.feature + ` can not be used with the "` + e.algName +
+		`" RNG algorithm`
+}
+
+// switchRandomAlgorithm switches to the algName RNG algorithm. The caller must
+// hold randMu.
+func switchRandomAlgorithm(algName string) error {
+	if err := randomBytesSwitchAlgorithm(algName); err != nil {
+		return err
+	}
+	randAlgorithmPtrCallback.Store(nil)
+	randAlgorithm.Store(algName)
 	return nil
 }
 
+// setCustomRandomAlgorithm switches to the custom RNG algorithm fun. The caller
+// must hold randMu.
+func setCustomRandomAlgorithm(fun func([]byte, int)) {
+	randAlgorithmPtrCallback.Store(&fun)
+	randomBytesCustomAlgorithm()
+	randAlgorithm.Store(CustomRandomAlgorithm)
+}
+
 /**************** END Randomness ****************/
@@ -0,0 +1,78 @@
+// The liboqs functions called by the liboqs backend, under the oqsgo_ prefix.
+// With the oqs_dlopen build tag, they are defined in backend_liboqs_dlopen.c
+// and forward to the library loaded at runtime, so that the package does not
+// define any OQS_ symbol, which would collide with another liboqs linked into
+// the same binary. Otherwise, they are the liboqs functions themselves.
+
+#ifndef OQSGO_H
+#define OQSGO_H
+
+#include <oqs/oqs.h>
+
+// The liboqs functions, with whether they are required from a library loaded
+// at runtime. The optional ones are missing from older liboqs versions, or, for
+// OQS_CPU_has_extension, reported as unavailable.
+#define OQSGO_SYMBOLS(X)                   \
+	X(OQS_init, 1)                         \
+	X(OQS_destroy, 1)                      \
+	X(OQS_thread_stop, 0)                  \
+	X(OQS_version, 1)                      \
+	X(OQS_CPU_has_extension, 0)            \
+	X(OQS_MEM_cleanse, 1)                  \
+	X(OQS_randombytes, 1)                  \
+	X(OQS_randombytes_switch_algorithm, 1) \
+	X(OQS_randombytes_custom_algorithm, 1) \
+	X(OQS_KEM_alg_count, 1)                \
+	X(OQS_KEM_alg_identifier, 1)           \
+	X(OQS_KEM_alg_is_enabled, 1)           \
+	X(OQS_KEM_new, 1)                      \
+	X(OQS_KEM_keypair, 1)                  \
+	X(OQS_KEM_encaps, 1)                   \
+	X(OQS_KEM_decaps, 1)                   \
+	X(OQS_KEM_free, 1)                     \
+	X(OQS_SIG_alg_count, 1)                \
+	X(OQS_SIG_alg_identifier, 1)           \
+	X(OQS_SIG_alg_is_enabled, 1)           \
+	X(OQS_SIG_new, 1)                      \
+	X(OQS_SIG_keypair, 1)                  \
+	X(OQS_SIG_sign, 1)                     \
+	X(OQS_SIG_sign_with_ctx_str, 0)        \
+	X(OQS_SIG_verify, 1)                   \
+	X(OQS_SIG_verify_with_ctx_str, 0)      \
+	X(OQS_SIG_free, 1)
+
+#ifdef OQSGO_DLOPEN
+#define OQSGO_DECLARE(name, required) extern __typeof__(name) oqsgo_##name;
+OQSGO_SYMBOLS(OQSGO_DECLARE)
+#undef OQSGO_DECLARE
+#else
+#define oqsgo_OQS_init OQS_init
+#define oqsgo_OQS_destroy OQS_destroy
+#define oqsgo_OQS_thread_stop OQS_thread_stop
+#define oqsgo_OQS_version OQS_version
+#define oqsgo_OQS_CPU_has_extension OQS_CPU_has_extension
+#define oqsgo_OQS_MEM_cleanse OQS_MEM_cleanse
+#define oqsgo_OQS_randombytes OQS_randombytes
+#define oqsgo_OQS_randombytes_switch_algorithm OQS_randombytes_switch_algorithm
+#define oqsgo_OQS_randombytes_custom_algorithm OQS_randombytes_custom_algorithm
+#define oqsgo_OQS_KEM_alg_count OQS_KEM_alg_count
+#define oqsgo_OQS_KEM_alg_identifier OQS_KEM_alg_identifier
+#define oqsgo_OQS_KEM_alg_is_enabled OQS_KEM_alg_is_enabled
+#define oqsgo_OQS_KEM_new OQS_KEM_new
+#define oqsgo_OQS_KEM_keypair OQS_KEM_keypair
+#define oqsgo_OQS_KEM_encaps OQS_KEM_encaps
+#define oqsgo_OQS_KEM_decaps OQS_KEM_decaps
+#define oqsgo_OQS_KEM_free OQS_KEM_free
+#define oqsgo_OQS_SIG_alg_count OQS_SIG_alg_count
+#define oqsgo_OQS_SIG_alg_identifier OQS_SIG_alg_identifier
+#define oqsgo_OQS_SIG_alg_is_enabled OQS_SIG_alg_is_enabled
+#define oqsgo_OQS_SIG_new OQS_SIG_new
+#define oqsgo_OQS_SIG_keypair OQS_SIG_keypair
+#define oqsgo_OQS_SIG_sign OQS_SIG_sign
+#define oqsgo_OQS_SIG_sign_with_ctx_str OQS_SIG_sign_with_ctx_str
+#define oqsgo_OQS_SIG_verify OQS_SIG_verify
+#define oqsgo_OQS_SIG_verify_with_ctx_str OQS_SIG_verify_with_ctx_str
+#define oqsgo_OQS_SIG_free OQS_SIG_free
+#endif
+
+#endif // OQSGO_H
@@ -0,0 +1,317 @@
+// Package oqstest provides deterministic fake implementations of the oqs.KEM,
+// oqs.Signer and oqs.Verifier interfaces, for testing code that depends on
+// them in isolation. The fakes honor the lengths declared by their details,
+// and each method can be configured to fail. They provide no security
+// whatsoever: anyone can forge their signatures and decapsulate their
+// ciphertexts.
+package oqstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+/**************** Details ****************/
+
+// KEMDetails returns the default details of a fake KEM, with the lengths of
+// ML-KEM-768.
+func KEMDetails() oqs.KeyEncapsulationDetails {
+	return oqs.KeyEncapsulationDetails{
+		Name:               "Fake-KEM",
+		Version:            "oqstest",
+		ClaimedNISTLevel:   3,
+		IsINDCCA:           true,
+		LengthPublicKey:    1184,
+		LengthSecretKey:    2400,
+		LengthCiphertext:   1088,
+		LengthSharedSecret: 32,
+	}
+}
+
+// SignatureDetails returns the default details of a fake signature, with the
+// lengths of ML-DSA-65.
+func SignatureDetails() oqs.SignatureDetails {
+	return oqs.SignatureDetails{
+		Name:               "Fake-Sig",
+		Version:            "oqstest",
+		ClaimedNISTLevel:   3,
+		IsEUFCMA:           true,
+		SigWithCtxSupport:  true,
+		LengthPublicKey:    1952,
+		LengthSecretKey:    4032,
+		MaxLengthSignature: 3309,
+	}
+}
+
+/**************** END Details ****************/
+
+/**************** KEM ****************/
+
+// KEM is a fake oqs.KEM. Key pairs and encapsulations are derived from the
+// algorithm name and a per-object counter, hence two KEMs with the same
+// details produce the same sequence of outputs. Decapsulation recovers the
+// shared secret of encapsulation, and yields a pseudorandom shared secret for
+// any other ciphertext of the right length.
+type KEM struct {
+	// GenerateKeyPairErr, EncapSecretErr and DecapSecretErr, if non-nil, are
+	// returned by the corresponding methods.
+	GenerateKeyPairErr error
+	EncapSecretErr     error
+	DecapSecretErr     error
+
+	details   oqs.KeyEncapsulationDetails
+	secretKey []byte
+	counter   uint64
+}
+
+// NewKEM returns a fake KEM with the given details, e.g., KEMDetails().
+func NewKEM(details oqs.KeyEncapsulationDetails) *KEM {
+	return &KEM{details: details}
+}
+
+// Details returns the fake KEM details.
+func (kem *KEM) Details() oqs.KeyEncapsulationDetails {
+	return kem.details
+}
+
+// GenerateKeyPair generates a pair of secret key/public key, keeps the secret
+// key and returns the public key.
+func (kem *KEM) GenerateKeyPair() ([]byte, error) {
+	if kem.GenerateKeyPairErr != nil {
+		return nil, kem.GenerateKeyPairErr
+	}
+
+	kem.secretKey = expand("sk", kem.details.LengthSecretKey,
+		[]byte(kem.details.Name), kem.nextCounter())
+	return expand("pk", kem.details.LengthPublicKey, kem.secretKey), nil
+}
+
+// ExportSecretKey returns the secret key of the kem receiver.
+func (kem *KEM) ExportSecretKey() []byte {
+	return kem.secretKey
+}
+
+// EncapSecret encapsulates a shared secret to publicKey.
+func (kem *KEM) EncapSecret(publicKey []byte) (ciphertext,
+	sharedSecret []byte, err error,
+) {
+	if kem.EncapSecretErr != nil {
+		return nil, nil, kem.EncapSecretErr
+	}
+
+	if len(publicKey) != kem.details.LengthPublicKey {
+		return nil, nil, errors.New("incorrect public key length")
+	}
+
+	ciphertext = expand("ct", kem.details.LengthCiphertext, publicKey,
+		kem.nextCounter())
+	sharedSecret = expand("ss", kem.details.LengthSharedSecret, publicKey,
+		ciphertext)
+	return ciphertext, sharedSecret, nil
+}
+
+// DecapSecret decapsulates the shared secret of ciphertext.
+func (kem *KEM) DecapSecret(ciphertext []byte) ([]byte, error) {
+	if kem.DecapSecretErr != nil {
+		return nil, kem.DecapSecretErr
+	}
+
+	if len(ciphertext) != kem.details.LengthCiphertext {
+		return nil, errors.New("incorrect ciphertext length")
+	}
+
+	if len(kem.secretKey) != kem.details.LengthSecretKey {
+		return nil, errors.New("incorrect secret key length, make sure you " +
+			"run GenerateKeyPair()")
+	}
+
+	publicKey := expand("pk", kem.details.LengthPublicKey, kem.secretKey)
+	return expand("ss", kem.details.LengthSharedSecret, publicKey,
+		ciphertext), nil
+}
+
+// Clean zeroes-in the secret key and resets the counter, keeping the details
+// and the configured errors.
+func (kem *KEM) Clean() {
+	clear(kem.secretKey)
+	kem.secretKey = nil
+	kem.counter = 0
+}
+
+// nextCounter returns the encoding of the counter of the kem receiver, and
+// increments it.
+func (kem *KEM) nextCounter() []byte {
+	kem.counter++
+	return binary.BigEndian.AppendUint64(nil, kem.counter)
+}
+
+var _ oqs.KEM = (*KEM)(nil)
+
+/**************** END KEM ****************/
+
+/**************** Signature ****************/
+
+// Signature is a fake oqs.Signer and oqs.Verifier. Key pairs are derived from
+// the algorithm name and a per-object counter. Signatures are
+// SignatureDetails.MaxLengthSignature bytes long, and are derived from the
+// public key, the context string and the message only, hence signing is
+// deterministic and verification does not need the secret key.
+type Signature struct {
+	// GenerateKeyPairErr, SignErr and VerifyErr, if non-nil, are returned by
+	// the corresponding methods, including the context string variants.
+	GenerateKeyPairErr error
+	SignErr            error
+	VerifyErr          error
+
+	details   oqs.SignatureDetails
+	secretKey []byte
+	counter   uint64
+}
+
+// NewSignature returns a fake signature with the given details, e.g.,
+// SignatureDetails().
+func NewSignature(details oqs.SignatureDetails) *Signature {
+	return &Signature{details: details}
+}
+
+// Details returns the fake signature details.
+func (sig *Signature) Details() oqs.SignatureDetails {
+	return sig.details
+}
+
+// GenerateKeyPair generates a pair of secret key/public key, keeps the secret
+// key and returns the public key.
+func (sig *Signature) GenerateKeyPair() ([]byte, error) {
+	if sig.GenerateKeyPairErr != nil {
+		return nil, sig.GenerateKeyPairErr
+	}
+
+	sig.counter++
+	sig.secretKey = expand("sk", sig.details.LengthSecretKey,
+		[]byte(sig.details.Name),
+		binary.BigEndian.AppendUint64(nil, sig.counter))
+	return expand("pk", sig.details.LengthPublicKey, sig.secretKey), nil
+}
+
+// ExportSecretKey returns the secret key of the sig receiver.
+func (sig *Signature) ExportSecretKey() []byte {
+	return sig.secretKey
+}
+
+// Sign signs a message.
+func (sig *Signature) Sign(message []byte) ([]byte, error) {
+	return sig.SignWithCtxStr(message, nil)
+}
+
+// SignWithCtxStr signs a message with context string.
+func (sig *Signature) SignWithCtxStr(message []byte,
+	context []byte,
+) ([]byte, error) {
+	if sig.SignErr != nil {
+		return nil, sig.SignErr
+	}
+
+	if err := sig.checkContext(context); err != nil {
+		return nil, err
+	}
+
+	if len(sig.secretKey) != sig.details.LengthSecretKey {
+		return nil, errors.New("incorrect secret key length, make sure you " +
+			"run GenerateKeyPair()")
+	}
+
+	publicKey := expand("pk", sig.details.LengthPublicKey, sig.secretKey)
+	return sig.signature(message, context, publicKey), nil
+}
+
+// Verify verifies the validity of a signed message.
+func (sig *Signature) Verify(message []byte, signature []byte,
+	publicKey []byte,
+) (bool, error) {
+	return sig.VerifyWithCtxStr(message, signature, nil, publicKey)
+}
+
+// VerifyWithCtxStr verifies the validity of a signed message with context
+// string.
+func (sig *Signature) VerifyWithCtxStr(message []byte, signature []byte,
+	context []byte, publicKey []byte,
+) (bool, error) {
+	if sig.VerifyErr != nil {
+		return false, sig.VerifyErr
+	}
+
+	if err := sig.checkContext(context); err != nil {
+		return false, err
+	}
+
+	if len(publicKey) != sig.details.LengthPublicKey {
+		return false, errors.New("incorrect public key length")
+	}
+
+	if len(signature) > sig.details.MaxLengthSignature {
+		return false, errors.New("incorrect signature size")
+	}
+
+	return bytes.Equal(signature, sig.signature(message, context, publicKey)),
+		nil
+}
+
+// Clean zeroes-in the secret key and resets the counter, keeping the details
+// and the configured errors.
+func (sig *Signature) Clean() {
+	clear(sig.secretKey)
+	sig.secretKey = nil
+	sig.counter = 0
+}
+
+// checkContext applies the context string checks of oqs.Signature.
+func (sig *Signature) checkContext(context []byte) error {
+	if len(context) > 0 && !sig.details.SigWithCtxSupport {
+		return errors.New("can not sign message with context string")
+	}
+
+	if len(context) > oqs.MaxLengthContext {
+		return errors.New("context string too long")
+	}
+
+	return nil
+}
+
+// signature returns the fake signature of message with context string under
+// publicKey.
+func (sig *Signature) signature(message, context, publicKey []byte) []byte {
+	return expand("sig", sig.details.MaxLengthSignature, publicKey, context,
+		message)
+}
+
+var (
+	_ oqs.Signer   = (*Signature)(nil)
+	_ oqs.Verifier = (*Signature)(nil)
+)
+
+/**************** END Signature ****************/
+
+// expand derives n bytes from a label and length-prefixed inputs with SHA-256
+// in counter mode.
+func expand(label string, n int, inputs ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(label))
+	for _, input := range inputs {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(input))))
+		h.Write(input)
+	}
+	prefix := h.Sum(nil)
+
+	out := make([]byte, 0, n+sha256.Size)
+	for block := uint32(0); len(out) < n; block++ {
+		h.Reset()
+		h.Write(prefix)
+		h.Write(binary.BigEndian.AppendUint32(nil, block))
+		out = h.Sum(out)
+	}
+	return out[:n]
+}
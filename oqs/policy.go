@@ -0,0 +1,353 @@
+package oqs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+/**************** Policy ****************/
+
+// PolicyEnv is the environment variable holding the default policy, either as
+// a JSON document or as the path of a JSON file. It is consulted by
+// KeyEncapsulation.Init and Signature.Init unless a policy was installed with
+// SetPolicy.
+const PolicyEnv = "LIBOQS_GO_POLICY"
+
+// The standardization statuses returned by StandardizationStatus.
+const (
+	// StatusStandardized is the status of the algorithms standardized by
+	// NIST, i.e., ML-KEM (FIPS 203), ML-DSA (FIPS 204) and SLH-DSA (FIPS 205).
+	StatusStandardized = "standardized"
+	// StatusDeprecated is the status of the submissions superseded by a
+	// standard, i.e., Kyber, Dilithium and SPHINCS+.
+	StatusDeprecated = "deprecated"
+	// StatusNonStandard is the status of every other algorithm.
+	StatusNonStandard = "nonstandard"
+)
+
+// The rules of a policy, as reported by PolicyError.Rule. They coincide with
+// the JSON keys of the Policy fields.
+const (
+	PolicyRuleMinNISTLevel        = "min_nist_level"
+	PolicyRuleAllowedFamilies     = "allowed_families"
+	PolicyRuleAllowedStatuses     = "allowed_statuses"
+	PolicyRuleMaxPublicKeyLength  = "max_public_key_length"
+	PolicyRuleMaxSecretKeyLength  = "max_secret_key_length"
+	PolicyRuleMaxCiphertextLength = "max_ciphertext_length"
+	PolicyRuleMaxSignatureLength  = "max_signature_length"
+)
+
+// Policy restricts the algorithms KeyEncapsulation.Init and Signature.Init
+// accept. The zero value of each field imposes no restriction. A policy can be
+// parsed from JSON, e.g.
+//
+//	{
+//	    "min_nist_level": 3,
+//	    "allowed_families": ["ML-KEM", "ML-DSA"],
+//	    "allowed_statuses": ["standardized"],
+//	    "max_public_key_length": 4096
+//	}
+type Policy struct {
+	// MinNISTLevel is the minimum claimed NIST security level.
+	MinNISTLevel int `json:"min_nist_level,omitempty"`
+	// AllowedFamilies lists the allowed algorithm families, as returned by
+	// AlgorithmFamily, e.g. "ML-KEM". Families are matched case-insensitively.
+	AllowedFamilies []string `json:"allowed_families,omitempty"`
+	// AllowedStatuses lists the allowed standardization statuses, as
+	// returned by StandardizationStatus.
+	AllowedStatuses []string `json:"allowed_statuses,omitempty"`
+	// MaxPublicKeyLength, MaxSecretKeyLength, MaxCiphertextLength and
+	// MaxSignatureLength limit the lengths in bytes of the corresponding
+	// algorithm details.
+	MaxPublicKeyLength  int `json:"max_public_key_length,omitempty"`
+	MaxSecretKeyLength  int `json:"max_secret_key_length,omitempty"`
+	MaxCiphertextLength int `json:"max_ciphertext_length,omitempty"`
+	MaxSignatureLength  int `json:"max_signature_length,omitempty"`
+	// Audit, if non-nil, is called with every decision taken by Init, both
+	// for allowed and denied algorithms.
+	Audit func(decision PolicyDecision) `json:"-"`
+}
+
+// PolicyDecision is a decision taken by KeyEncapsulation.Init or
+// Signature.Init, as reported to Policy.Audit.
+type PolicyDecision struct {
+	Algorithm string
+	Type      string       // "KEM" or "signature"
+	Err       *PolicyError // nil if the algorithm is allowed
+}
+
+// PolicyError is returned by KeyEncapsulation.Init and Signature.Init when the
+// algorithm violates the policy in effect.
+type PolicyError struct {
+	Algorithm string
+	Rule      string // the violated rule, e.g. PolicyRuleMinNISTLevel
+	Reason    string
+}
+
+func (e *PolicyError) Error() string {
+	return `"` + e.Algorithm + `" is not allowed by the policy: ` + e.Reason
+}
+
+// algorithmFamilies maps the name prefixes of the liboqs algorithms to their
+// families, longest prefixes first where they overlap.
+var algorithmFamilies = []struct{ prefix, family string }{
+	{"ML-KEM", "ML-KEM"},
+	{"ML-DSA", "ML-DSA"},
+	{"SLH_DSA", "SLH-DSA"},
+	{"SLH-DSA", "SLH-DSA"},
+	{"Kyber", "Kyber"},
+	{"Dilithium", "Dilithium"},
+	{"SPHINCS+", "SPHINCS+"},
+	{"Falcon", "Falcon"},
+	{"BIKE", "BIKE"},
+	{"Classic-McEliece", "Classic-McEliece"},
+	{"HQC", "HQC"},
+	{"eFrodoKEM", "FrodoKEM"},
+	{"FrodoKEM", "FrodoKEM"},
+	{"sntrup", "NTRU-Prime"},
+	{"MAYO", "MAYO"},
+	{"cross", "CROSS"},
+	{"OV-", "UOV"},
+	{"SNOVA", "SNOVA"},
+}
+
+// AlgorithmFamily returns the family of a liboqs algorithm, e.g. "ML-KEM" for
+// "ML-KEM-768", "SLH-DSA" for "SLH_DSA_PURE_SHA2_128S" or "FrodoKEM" for
+// "eFrodoKEM-640-AES". Unknown algorithms are their own family.
+func AlgorithmFamily(algName string) string {
+	for _, f := range algorithmFamilies {
+		if strings.HasPrefix(algName, f.prefix) {
+			return f.family
+		}
+	}
+	return algName
+}
+
+// StandardizationStatus returns the standardization status of a liboqs
+// algorithm, i.e., StatusStandardized, StatusDeprecated or StatusNonStandard.
+func StandardizationStatus(algName string) string {
+	switch AlgorithmFamily(algName) {
+	case "ML-KEM", "ML-DSA", "SLH-DSA":
+		return StatusStandardized
+	case "Kyber", "Dilithium", "SPHINCS+":
+		return StatusDeprecated
+	default:
+		return StatusNonStandard
+	}
+}
+
+// ParsePolicy parses a JSON policy. Unknown keys and statuses, as well as data
+// following the policy object, are rejected.
+func ParsePolicy(data []byte) (*Policy, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var p Policy
+	if err := dec.Decode(&p); err != nil {
+		return nil, errors.New("invalid policy: " + err.Error())
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid policy: unexpected data after the " +
+			"policy object")
+	}
+	for _, status := range p.AllowedStatuses {
+		switch status {
+		case StatusStandardized, StatusDeprecated, StatusNonStandard:
+		default:
+			return nil, errors.New(`invalid policy: unknown status "` +
+				status + `"`)
+		}
+	}
+
+	return &p, nil
+}
+
+// PolicyFromEnv returns the policy held by the PolicyEnv environment variable,
+// or nil if the variable is unset or empty.
+func PolicyFromEnv() (*Policy, error) {
+	value := strings.TrimSpace(os.Getenv(PolicyEnv))
+	if value == "" {
+		return nil, nil
+	}
+
+	data := []byte(value)
+	if !strings.HasPrefix(value, "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, errors.New("can not read the policy in " + PolicyEnv +
+				": " + err.Error())
+		}
+	}
+	return ParsePolicy(data)
+}
+
+// policy holds the policy installed with SetPolicy, and the policy parsed from
+// the PolicyEnv environment variable, which is re-parsed when it changes.
+var policy struct {
+	sync.Mutex
+	installed bool
+	p         *Policy
+	env       string
+	envPolicy *Policy
+	envErr    error
+}
+
+// SetPolicy installs p as the process-wide policy, overriding the PolicyEnv
+// environment variable. A nil p lifts every restriction. The policy must not
+// be modified once installed.
+func SetPolicy(p *Policy) {
+	policy.Lock()
+	policy.installed = true
+	policy.p = p
+	policy.Unlock()
+}
+
+// ResetPolicy removes the policy installed with SetPolicy, reverting to the
+// policy held by the PolicyEnv environment variable, if any.
+func ResetPolicy() {
+	policy.Lock()
+	policy.installed = false
+	policy.p = nil
+	policy.Unlock()
+}
+
+// CurrentPolicy returns the policy in effect, i.e., the policy installed with
+// SetPolicy, or the policy held by the PolicyEnv environment variable, which
+// is parsed again whenever its value changes. It returns nil if no policy is
+// in effect.
+func CurrentPolicy() (*Policy, error) {
+	policy.Lock()
+	defer policy.Unlock()
+	if policy.installed {
+		return policy.p, nil
+	}
+
+	if value := os.Getenv(PolicyEnv); value != policy.env {
+		policy.env = value
+		policy.envPolicy, policy.envErr = PolicyFromEnv()
+	}
+	return policy.envPolicy, policy.envErr
+}
+
+// CheckKEM returns a *PolicyError if a KEM with the given details violates the
+// policy, and nil otherwise.
+func (p *Policy) CheckKEM(details KeyEncapsulationDetails) error {
+	if err := p.check(details.Name, details.ClaimedNISTLevel); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxPublicKeyLength,
+		"public key", details.LengthPublicKey,
+		p.MaxPublicKeyLength); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxSecretKeyLength,
+		"secret key", details.LengthSecretKey,
+		p.MaxSecretKeyLength); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxCiphertextLength,
+		"ciphertext", details.LengthCiphertext,
+		p.MaxCiphertextLength); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckSignature returns a *PolicyError if a signature algorithm with the
+// given details violates the policy, and nil otherwise.
+func (p *Policy) CheckSignature(details SignatureDetails) error {
+	if err := p.check(details.Name, details.ClaimedNISTLevel); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxPublicKeyLength,
+		"public key", details.LengthPublicKey,
+		p.MaxPublicKeyLength); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxSecretKeyLength,
+		"secret key", details.LengthSecretKey,
+		p.MaxSecretKeyLength); err != nil {
+		return err
+	}
+	if err := checkLength(details.Name, PolicyRuleMaxSignatureLength,
+		"signature", details.MaxLengthSignature,
+		p.MaxSignatureLength); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// check applies the rules common to KEMs and signatures.
+func (p *Policy) check(algName string, nistLevel int) *PolicyError {
+	if nistLevel < p.MinNISTLevel {
+		return &PolicyError{Algorithm: algName, Rule: PolicyRuleMinNISTLevel,
+			Reason: "claimed NIST level " + strconv.Itoa(nistLevel) +
+				" is below " + strconv.Itoa(p.MinNISTLevel)}
+	}
+
+	if len(p.AllowedFamilies) > 0 {
+		family := AlgorithmFamily(algName)
+		allowed := false
+		for _, f := range p.AllowedFamilies {
+			allowed = allowed || strings.EqualFold(f, family)
+		}
+		if !allowed {
+			return &PolicyError{Algorithm: algName,
+				Rule:   PolicyRuleAllowedFamilies,
+				Reason: `family "` + family + `" is not allowed`}
+		}
+	}
+
+	if len(p.AllowedStatuses) > 0 {
+		status := StandardizationStatus(algName)
+		allowed := false
+		for _, s := range p.AllowedStatuses {
+			allowed = allowed || s == status
+		}
+		if !allowed {
+			return &PolicyError{Algorithm: algName,
+				Rule:   PolicyRuleAllowedStatuses,
+				Reason: `status "` + status + `" is not allowed`}
+		}
+	}
+
+	return nil
+}
+
+// checkLength checks a length against a limit, if positive.
+func checkLength(algName, rule, what string, length, limit int) error {
+	if limit > 0 && length > limit {
+		return &PolicyError{Algorithm: algName, Rule: rule,
+			Reason: what + " length " + strconv.Itoa(length) +
+				" exceeds " + strconv.Itoa(limit) + " bytes"}
+	}
+	return nil
+}
+
+// enforcePolicy checks the details of an algorithm against the policy in
+// effect with check, and reports the decision to the audit callback.
+func enforcePolicy(algName, algType string, check func(*Policy) error) error {
+	p, err := CurrentPolicy()
+	if err != nil || p == nil {
+		return err
+	}
+
+	decision := PolicyDecision{Algorithm: algName, Type: algType}
+	err = check(p)
+	if err != nil {
+		decision.Err = err.(*PolicyError)
+	}
+	if p.Audit != nil {
+		p.Audit(decision)
+	}
+	return err
+}
+
+/**************** END Policy ****************/
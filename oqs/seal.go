@@ -0,0 +1,227 @@
+//go:build go1.24
+
+package oqs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs/kdf"
+)
+
+/**************** Sealed envelopes ****************/
+
+// Seal and KeyEncapsulation.Open encrypt a message to a public key with a KEM
+// and an AEAD (KEM-DEM). The sealed envelope is
+//
+//	version || AEAD || KEM ciphertext || nonce || AEAD ciphertext and tag
+//
+// where version is a byte, currently 1, AEAD is a byte identifying the AEAD,
+// and the nonce is 12 bytes long. The AEAD key is derived from the shared
+// secret with kdf.AEADKey, which binds the KEM algorithm, the KEM ciphertext,
+// the public key of the recipient and the version and AEAD bytes, hence the
+// recipient opens the envelope with the public key kept by its
+// KeyEncapsulation, see KeyEncapsulation.ImportPublicKey. Sealing and opening
+// require Go 1.24 or later.
+
+// envelopeVersion is the version byte of the sealed envelopes.
+const envelopeVersion = 1
+
+// envelopeNonceSize is the nonce length of the AEAD.
+const envelopeNonceSize = 12
+
+// envelopeOverhead is the tag length of the AEAD.
+const envelopeOverhead = 16
+
+// AEAD identifies the AEAD of a sealed envelope, see WithAEAD. The identifier
+// 2 is reserved for ChaCha20-Poly1305 (RFC 8439).
+type AEAD uint8
+
+// AES256GCM is AES-256 in Galois/Counter Mode, the default.
+const AES256GCM AEAD = 1
+
+// String returns the name of the AEAD, e.g. "AES-256-GCM".
+func (a AEAD) String() string {
+	if a == AES256GCM {
+		return "AES-256-GCM"
+	}
+	return "AEAD(" + strconv.Itoa(int(a)) + ")"
+}
+
+// ErrEnvelopeTruncated is returned by KeyEncapsulation.Open when the sealed
+// envelope is shorter than its header, KEM ciphertext, nonce and tag.
+var ErrEnvelopeTruncated = errors.New("sealed envelope is truncated")
+
+// ErrEnvelopeUnsupported is returned by KeyEncapsulation.Open when the sealed
+// envelope has an unknown version or AEAD.
+var ErrEnvelopeUnsupported = errors.New("sealed envelope has an unsupported " +
+	"version or AEAD")
+
+// ErrEnvelopeAuthentication is returned by KeyEncapsulation.Open when the
+// sealed envelope, or the additional data, was tampered with, or when the
+// envelope was sealed to another public key. It is wrapped together with the
+// error of the decapsulation, if it failed.
+var ErrEnvelopeAuthentication = errors.New("sealed envelope can not be " +
+	"authenticated")
+
+// envelopeAuthenticationError is the ErrEnvelopeAuthentication of an envelope
+// whose KEM ciphertext was rejected by the decapsulation.
+type envelopeAuthenticationError struct {
+	err error
+}
+
+func (e *envelopeAuthenticationError) Error() string {
+	return ErrEnvelopeAuthentication.Error() + ": " + e.err.Error()
+}
+
+func (e *envelopeAuthenticationError) Unwrap() []error {
+	return []error{ErrEnvelopeAuthentication, e.err}
+}
+
+// WithAEAD selects the AEAD of Seal, AES256GCM by default, which is currently
+// the only one supported. The other methods ignore it, and
+// KeyEncapsulation.Open reads the AEAD from the envelope.
+func WithAEAD(aead AEAD) Option {
+	return func(o *options) {
+		o.aead = uint8(aead)
+	}
+}
+
+// Seal encrypts plaintext to publicKey with the algName KEM and an AEAD, and
+// authenticates plaintext and aad, which is not included in the returned
+// envelope. The recipient opens it with KeyEncapsulation.Open, passing the
+// same aad. The opts configure the KEM as for KeyEncapsulation.Init, e.g. with
+// WithStrictValidation, and select the AEAD with WithAEAD. The nonce is read
+// from the reader set with WithRandomReader, if any, or from Reader.
+func Seal(algName string, publicKey, plaintext, aad []byte,
+	opts ...Option,
+) ([]byte, error) {
+	o := newOptions(opts)
+	aeadID := AEAD(o.aead)
+	if aeadID == 0 {
+		aeadID = AES256GCM
+	}
+	if aeadID != AES256GCM {
+		return nil, errors.New("unsupported AEAD " + aeadID.String())
+	}
+
+	var kem KeyEncapsulation
+	if err := kem.Init(algName, nil, opts...); err != nil {
+		return nil, err
+	}
+	defer kem.Clean()
+	ciphertext, sharedSecret, err := kem.EncapSecret(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer MemCleanse(sharedSecret)
+
+	header := []byte{envelopeVersion, byte(aeadID)}
+	aead, err := envelopeAEAD(kem.algDetails.Name, header, sharedSecret,
+		ciphertext, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed := make([]byte, 0, len(header)+len(ciphertext)+envelopeNonceSize+
+		len(plaintext)+envelopeOverhead)
+	sealed = append(sealed, header...)
+	sealed = append(sealed, ciphertext...)
+	nonce := sealed[len(sealed) : len(sealed)+envelopeNonceSize]
+	random := o.rand
+	if random == nil {
+		random = Reader
+	}
+	if _, err := io.ReadFull(random, nonce); err != nil {
+		return nil, errors.New("can not read the nonce: " + err.Error())
+	}
+	sealed = sealed[:len(sealed)+envelopeNonceSize]
+	return aead.Seal(sealed, nonce, plaintext, aad), nil
+}
+
+// Open decrypts an envelope sealed with Seal to the key pair of the kem
+// receiver, and authenticates it together with aad. The receiver must have been
+// initialized with the algorithm of the envelope, and hold both the secret key
+// and the public key, i.e. run KeyEncapsulation.GenerateKeyPair, or be passed
+// the secret key in KeyEncapsulation.Init and the public key with
+// KeyEncapsulation.ImportPublicKey. It returns ErrEnvelopeTruncated,
+// ErrEnvelopeUnsupported or ErrEnvelopeAuthentication for malformed or
+// tampered envelopes, including when the decapsulation rejects the KEM
+// ciphertext, e.g. in strict mode.
+func (kem *KeyEncapsulation) Open(sealed, aad []byte) ([]byte, error) {
+	if _, err := kem.handle.acquire(); err != nil {
+		return nil, err
+	}
+	defer kem.handle.release()
+	if len(kem.secretKey) != kem.algDetails.LengthSecretKey {
+		return nil, errors.New("incorrect secret key length, make sure you " +
+			"specify one in Init() or run GenerateKeyPair()")
+	}
+	if len(kem.publicKey) != kem.algDetails.LengthPublicKey {
+		return nil, errors.New("the public key is missing, make sure you " +
+			"run GenerateKeyPair() or ImportPublicKey()")
+	}
+
+	const headerSize = 2
+	if len(sealed) < headerSize {
+		return nil, ErrEnvelopeTruncated
+	}
+	header := sealed[:headerSize]
+	if header[0] != envelopeVersion {
+		return nil, ErrEnvelopeUnsupported
+	}
+	if AEAD(header[1]) != AES256GCM {
+		return nil, ErrEnvelopeUnsupported
+	}
+	lengthCiphertext := kem.algDetails.LengthCiphertext
+	if len(sealed) < headerSize+lengthCiphertext+envelopeNonceSize+
+		envelopeOverhead {
+		return nil, ErrEnvelopeTruncated
+	}
+	ciphertext := sealed[headerSize : headerSize+lengthCiphertext]
+	nonce := sealed[headerSize+lengthCiphertext:][:envelopeNonceSize]
+	body := sealed[headerSize+lengthCiphertext+envelopeNonceSize:]
+
+	sharedSecret, err := kem.DecapSecret(ciphertext)
+	if err != nil {
+		// The receiver may be cleaned, or liboqs shut down, in the meantime
+		if errors.Is(err, ErrCleaned) || errors.Is(err, ErrShutdown) {
+			return nil, err
+		}
+		return nil, &envelopeAuthenticationError{err: err}
+	}
+	defer MemCleanse(sharedSecret)
+	aead, err := envelopeAEAD(kem.algDetails.Name, header, sharedSecret,
+		ciphertext, kem.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, body, aad)
+	if err != nil {
+		return nil, ErrEnvelopeAuthentication
+	}
+	return plaintext, nil
+}
+
+// envelopeAEAD returns the AES-256-GCM AEAD of header, keyed with the key
+// derived from the sharedSecret output by the algName KEM for ciphertext and
+// publicKey.
+func envelopeAEAD(algName string, header, sharedSecret, ciphertext,
+	publicKey []byte,
+) (cipher.AEAD, error) {
+	key, err := kdf.AEADKey(algName, sharedSecret, ciphertext, publicKey,
+		header, 32)
+	if err != nil {
+		return nil, err
+	}
+	defer MemCleanse(key)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+/**************** END Sealed envelopes ****************/
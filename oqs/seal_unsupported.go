@@ -0,0 +1,8 @@
+//go:build !go1.24
+
+package oqs
+
+// The sealed envelopes derive their AEAD key with the oqs/kdf package, which
+// requires Go 1.24 or later, see seal.go. With an older Go toolchain, Seal,
+// KeyEncapsulation.Open, WithAEAD, the AEAD type and AES256GCM, and the
+// ErrEnvelope errors are not defined, and the aead field of options is unused.
@@ -0,0 +1,349 @@
+package oqs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+/**************** Self-tests ****************/
+
+// The self-tests run a known-answer test of an algorithm before its first use,
+// i.e., they derive a key pair and a signature, or the decapsulation of a fixed
+// ciphertext, from a fixed seed, check that the results are consistent, and
+// compare their digest with the expected one. For the algorithms without a
+// recorded answer, the self-tests only check that the results are consistent
+// and reproducible, and report their failures as SelfTestReproducibility. The
+// results are cached for the lifetime of the process.
+//
+// The self-tests feed the seed through a per-object reader, see
+// WithRandomReader, hence the "OpenSSL" RNG algorithm of the liboqs backend can
+// not be selected while a self-test runs. A self-test run while the "OpenSSL"
+// RNG algorithm is in use returns an error that is not cached, and the
+// algorithm is tested again on its next use.
+
+// The self-tests, as reported by SelfTestError.Test.
+const (
+	SelfTestKnownAnswer         = "known-answer"
+	SelfTestReproducibility     = "reproducibility"
+	SelfTestPairwiseConsistency = "pairwise consistency"
+)
+
+// SelfTestError is returned when a self-test or a pairwise consistency check
+// fails.
+type SelfTestError struct {
+	Algorithm string
+	Test      string // One of the SelfTest constants
+	Reason    string
+}
+
+func (e *SelfTestError) Error() string {
+	return `"` + e.Algorithm + `" ` + e.Test + " self-test failed: " +
+		e.Reason
+}
+
+// selfTestSeed and selfTestMessage are the inputs of the known-answer tests.
+const (
+	selfTestSeed    = "liboqs-go self-test seed"
+	selfTestMessage = "liboqs-go self-test message"
+)
+
+// selfTestAnswers maps the algorithms to the hex-encoded SHA-256 digest of
+// pk || ss for KEMs, where ss is the decapsulation of the first bytes of the
+// self-test stream, and of pk || sig for signature algorithms, where ML-DSA
+// signatures are deterministic. The KEM answers do not cover encapsulation,
+// which the pure-Go backend can not derandomize. The answers were computed with
+// the crypto/mlkem and crypto/mldsa packages of the Go standard library, except
+// for ML-KEM-512, which crypto/mlkem does not implement, and whose answer was
+// computed with an independent implementation of FIPS 203 that reproduces the
+// ML-KEM-768 and ML-KEM-1024 answers.
+var selfTestAnswers = map[string]string{
+	"ML-KEM-512": "6566b27ccc95e482dc87dc037fa184f4" +
+		"c03aa28d921e052b8925bc2c2594dbe8",
+	"ML-KEM-768": "ae4f602a98fd63ec6c4e8656471f374a" +
+		"6c514e27a2708444edac5b1fa930d40e",
+	"ML-KEM-1024": "d924e564b5c19c4abb12e7e986fbc4eb" +
+		"888e94bdfbd93d3083a0932f232801c5",
+	"ML-DSA-44": "236c378f3a22f02d6f4f9f52d0f00a24" +
+		"2995652042e491712f1ba784de8db2d1",
+	"ML-DSA-65": "97fd06d3461e3351ac7fd48a6cb869fa" +
+		"f8652476b7b10354a965cfd9ce61ebb4",
+	"ML-DSA-87": "3f9b10a0c57ccb5674648f56e598573c" +
+		"094cf815a77901b313fecb733c9ff5ce",
+}
+
+// selfTestsEnabled is true once EnableSelfTests is called.
+var selfTestsEnabled atomic.Bool
+
+// selfTestResults caches the results of the self-tests, keyed by "KEM/" or
+// "sig/" followed by the algorithm name.
+var selfTestResults sync.Map // map[string]*selfTestResult
+
+type selfTestResult struct {
+	mu   sync.Mutex
+	done bool
+	err  error
+}
+
+// EnableSelfTests makes KeyEncapsulation.Init and Signature.Init run the
+// self-test of their algorithm on its first use, and fail with a
+// *SelfTestError if it does not pass. It also enables the pairwise consistency
+// check of every generated key pair, see WithPairwiseConsistencyCheck.
+func EnableSelfTests() {
+	selfTestsEnabled.Store(true)
+}
+
+// DisableSelfTests disables the self-tests enabled with EnableSelfTests. The
+// cached results are kept.
+func DisableSelfTests() {
+	selfTestsEnabled.Store(false)
+}
+
+// SelfTestKEM runs the self-test of a KEM, or returns its cached result.
+func SelfTestKEM(algName string) error {
+	return cachedSelfTest("KEM/"+algName, func() error {
+		return selfTestKEM(algName)
+	})
+}
+
+// SelfTestSig runs the self-test of a signature algorithm, or returns its
+// cached result.
+func SelfTestSig(algName string) error {
+	return cachedSelfTest("sig/"+algName, func() error {
+		return selfTestSig(algName)
+	})
+}
+
+// cachedSelfTest runs test once per key, or until it no longer fails with a
+// *randomDispatchError.
+func cachedSelfTest(key string, test func() error) error {
+	v, _ := selfTestResults.LoadOrStore(key, &selfTestResult{})
+	result := v.(*selfTestResult)
+	result.mu.Lock()
+	defer result.mu.Unlock()
+	if !result.done {
+		err := test()
+		var dispatchErr *randomDispatchError
+		if errors.As(err, &dispatchErr) {
+			return err
+		}
+		result.err = err
+		result.done = true
+	}
+	return result.err
+}
+
+// selfTestKEM runs the known-answer test of a KEM.
+func selfTestKEM(algName string) error {
+	answer := func() ([]byte, error) {
+		b, err := newKEMBackend(algName, newSelfTestReader())
+		if err != nil {
+			return nil, err
+		}
+		defer b.free()
+		details := b.details()
+		publicKey := make([]byte, details.LengthPublicKey)
+		secretKey := make([]byte, details.LengthSecretKey)
+		ciphertext := make([]byte, details.LengthCiphertext)
+		sharedSecret := make([]byte, details.LengthSharedSecret)
+		recovered := make([]byte, details.LengthSharedSecret)
+		if err := b.keypair(publicKey, secretKey); err != nil {
+			return nil, err
+		}
+		if err := b.encaps(ciphertext, sharedSecret, publicKey); err != nil {
+			return nil, err
+		}
+		if err := b.decaps(recovered, ciphertext, secretKey); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(recovered, sharedSecret) {
+			return nil, errors.New("shared secrets differ")
+		}
+		// The KEMs with implicit rejection, e.g. ML-KEM, decapsulate any
+		// ciphertext of the right length
+		if _, err := newSelfTestReader().Read(ciphertext); err != nil {
+			return nil, err
+		}
+		if err := b.decaps(recovered, ciphertext, secretKey); err != nil {
+			return digest(publicKey), nil
+		}
+		return digest(publicKey, recovered), nil
+	}
+	return checkAnswer(algName, answer)
+}
+
+// selfTestSig runs the known-answer test of a signature algorithm.
+func selfTestSig(algName string) error {
+	answer := func() ([]byte, error) {
+		b, err := newSigBackend(algName, newSelfTestReader())
+		if err != nil {
+			return nil, err
+		}
+		defer b.free()
+		details := b.details()
+		publicKey := make([]byte, details.LengthPublicKey)
+		secretKey := make([]byte, details.LengthSecretKey)
+		signature := make([]byte, details.MaxLengthSignature)
+		message := []byte(selfTestMessage)
+		if err := b.keypair(publicKey, secretKey); err != nil {
+			return nil, err
+		}
+		var lenSig int
+		if _, ok := mldsaParamSets[algName]; ok {
+			lenSig, err = b.signDeterministic(signature, message, nil,
+				secretKey)
+		} else {
+			lenSig, err = b.sign(signature, message, secretKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+		signature = signature[:lenSig]
+		if !b.verify(message, signature, publicKey) {
+			return nil, errors.New("signature rejected")
+		}
+		message[0] ^= 1
+		if b.verify(message, signature, publicKey) {
+			return nil, errors.New("signature of another message accepted")
+		}
+		return digest(publicKey, signature), nil
+	}
+	return checkAnswer(algName, answer)
+}
+
+// checkAnswer compares the result of answer with the recorded answer of
+// algName, or, if there is none, with the result of a second run.
+func checkAnswer(algName string, answer func() ([]byte, error)) error {
+	expected, known := selfTestAnswers[algName]
+	test := SelfTestKnownAnswer
+	if !known {
+		test = SelfTestReproducibility
+	}
+
+	got, err := answer()
+	if err != nil {
+		return wrapSelfTestError(algName, test, err)
+	}
+	if !known {
+		again, err := answer()
+		if err != nil {
+			return wrapSelfTestError(algName, test, err)
+		}
+		expected = hex.EncodeToString(again)
+	}
+	if hex.EncodeToString(got) != expected {
+		return &SelfTestError{Algorithm: algName, Test: test,
+			Reason: "unexpected answer"}
+	}
+
+	return nil
+}
+
+// wrapSelfTestError turns err into a *SelfTestError of the given test, unless
+// it is a *randomDispatchError, which does not tell anything about the
+// algorithm.
+func wrapSelfTestError(algName, test string, err error) error {
+	var dispatchErr *randomDispatchError
+	if errors.As(err, &dispatchErr) {
+		return err
+	}
+	return &SelfTestError{Algorithm: algName, Test: test, Reason: err.Error()}
+}
+
+// digest returns the SHA-256 digest of the concatenation of parts.
+func digest(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// selfTestReader is the deterministic stream of the known-answer tests,
+// SHA-256(selfTestSeed || counter) for counter = 0, 1, ...
+type selfTestReader struct {
+	counter uint64
+	buf     []byte
+}
+
+func newSelfTestReader() *selfTestReader {
+	return &selfTestReader{}
+}
+
+func (r *selfTestReader) Read(b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		if len(r.buf) == 0 {
+			block := sha256.Sum256(binary.BigEndian.AppendUint64(
+				[]byte(selfTestSeed), r.counter))
+			r.buf = block[:]
+			r.counter++
+		}
+		c := copy(b[n:], r.buf)
+		r.buf = r.buf[c:]
+		n += c
+	}
+	return n, nil
+}
+
+/**************** END Self-tests ****************/
+
+/**************** Pairwise consistency ****************/
+
+// WithPairwiseConsistencyCheck makes KeyEncapsulation.GenerateKeyPair check
+// each generated key pair with an encapsulation and a decapsulation, and
+// Signature.GenerateKeyPair with a signature and its verification. A key pair
+// that fails the check is zeroed, and a *SelfTestError is returned. The check
+// is also enabled by EnableSelfTests.
+func WithPairwiseConsistencyCheck() Option {
+	return func(o *options) {
+		o.pairwise = true
+	}
+}
+
+// pairwiseConsistencyKEM checks a KEM key pair.
+func pairwiseConsistencyKEM(b kemBackend, publicKey, secretKey []byte) error {
+	details := b.details()
+	ciphertext := make([]byte, details.LengthCiphertext)
+	sharedSecret := make([]byte, details.LengthSharedSecret)
+	recovered := make([]byte, details.LengthSharedSecret)
+	defer MemCleanse(sharedSecret)
+	defer MemCleanse(recovered)
+	if err := b.encaps(ciphertext, sharedSecret, publicKey); err != nil {
+		return pairwiseError(details.Name, err.Error())
+	}
+	if err := b.decaps(recovered, ciphertext, secretKey); err != nil {
+		return pairwiseError(details.Name, err.Error())
+	}
+	if !bytes.Equal(recovered, sharedSecret) {
+		return pairwiseError(details.Name, "shared secrets differ")
+	}
+	return nil
+}
+
+// pairwiseConsistencySig checks a signature key pair.
+func pairwiseConsistencySig(b sigBackend, publicKey, secretKey []byte) error {
+	details := b.details()
+	signature := make([]byte, details.MaxLengthSignature)
+	message := []byte(selfTestMessage)
+	lenSig, err := b.sign(signature, message, secretKey)
+	if err != nil {
+		return pairwiseError(details.Name, err.Error())
+	}
+	if !b.verify(message, signature[:lenSig], publicKey) {
+		return pairwiseError(details.Name, "signature rejected")
+	}
+	return nil
+}
+
+// pairwiseError returns a pairwise consistency *SelfTestError.
+func pairwiseError(algName, reason string) error {
+	return &SelfTestError{Algorithm: algName,
+		Test: SelfTestPairwiseConsistency, Reason: reason}
+}
+
+/**************** END Pairwise consistency ****************/
@@ -0,0 +1,170 @@
+package oqs
+
+import (
+	"errors"
+	"strings"
+)
+
+/**************** Validation ****************/
+
+// mlkemQ is the ML-KEM modulus q, see FIPS 203, Section 2.4.
+const mlkemQ = 3329
+
+// mlkemRank maps the ML-KEM parameter sets to their module rank k, see
+// FIPS 203, Section 8.
+var mlkemRank = map[string]int{
+	"ML-KEM-512":  2,
+	"ML-KEM-768":  3,
+	"ML-KEM-1024": 4,
+}
+
+// mldsaParams holds the ML-DSA parameters relevant to signature decoding, see
+// FIPS 204, Section 4.
+type mldsaParams struct {
+	lenCTilde int // length of the commitment hash c~, lambda/4 bytes
+	k         int // number of rows of the matrix A
+	l         int // number of columns of the matrix A
+	gamma1    int // coefficient range of y, as a power of two exponent
+	omega     int // maximum number of 1's in the hint h
+}
+
+// lenSignature returns the length in bytes of an encoded ML-DSA signature.
+func (p mldsaParams) lenSignature() int {
+	return p.lenCTilde + p.l*32*(p.gamma1+1) + p.omega + p.k
+}
+
+// mldsaParamSets maps the ML-DSA parameter sets to their parameters.
+var mldsaParamSets = map[string]mldsaParams{
+	"ML-DSA-44": {lenCTilde: 32, k: 4, l: 4, gamma1: 17, omega: 80},
+	"ML-DSA-65": {lenCTilde: 48, k: 6, l: 5, gamma1: 19, omega: 55},
+	"ML-DSA-87": {lenCTilde: 64, k: 8, l: 7, gamma1: 19, omega: 75},
+}
+
+// isSLHDSA returns true if algName belongs to the SLH-DSA (SPHINCS+) family,
+// and false otherwise.
+func isSLHDSA(algName string) bool {
+	return strings.HasPrefix(algName, "SLH_DSA") ||
+		strings.HasPrefix(algName, "SLH-DSA") ||
+		strings.HasPrefix(algName, "SPHINCS+")
+}
+
+// mlkemModulusCheck implements the encapsulation key check of FIPS 203,
+// Section 7.2, i.e., verifies that ByteEncode12(ByteDecode12(ek)) == ek, which
+// amounts to every 12-bit coefficient of the encoded vector being reduced
+// modulo q.
+func mlkemModulusCheck(publicKey []byte, k int) bool {
+	encoded := publicKey[:384*k]
+	for i := 0; i < len(encoded); i += 3 {
+		d1 := uint16(encoded[i]) | uint16(encoded[i+1]&0x0f)<<8
+		d2 := uint16(encoded[i+1]>>4) | uint16(encoded[i+2])<<4
+		if d1 >= mlkemQ || d2 >= mlkemQ {
+			return false
+		}
+	}
+	return true
+}
+
+// mldsaHintCheck verifies that the hint part of an ML-DSA signature is well
+// formed, i.e., that HintBitUnpack of FIPS 204, Algorithm 21, does not return
+// an error.
+func mldsaHintCheck(hint []byte, p mldsaParams) bool {
+	index := 0
+	for i := 0; i < p.k; i++ {
+		limit := int(hint[p.omega+i])
+		if limit < index || limit > p.omega {
+			return false
+		}
+		first := index
+		for ; index < limit; index++ {
+			if index > first && hint[index-1] >= hint[index] {
+				return false
+			}
+		}
+	}
+	for ; index < p.omega; index++ {
+		if hint[index] != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidatePublicKey checks that publicKey is a well-formed public key for the
+// KEM algorithm. For ML-KEM, this performs the encapsulation key check
+// mandated by FIPS 203, Section 7.2. For the other algorithms, only the length
+// is checked.
+func (kem *KeyEncapsulation) ValidatePublicKey(publicKey []byte) error {
+	if len(publicKey) != kem.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+	if k, ok := mlkemRank[kem.algDetails.Name]; ok {
+		if !mlkemModulusCheck(publicKey, k) {
+			return errors.New("public key fails the FIPS 203 modulus check")
+		}
+	}
+	return nil
+}
+
+// ValidateCiphertext checks that ciphertext is a well-formed ciphertext for
+// the KEM algorithm. This implements the ciphertext type check of FIPS 203,
+// Section 7.3, which is also applied to the non-ML-KEM algorithms.
+func (kem *KeyEncapsulation) ValidateCiphertext(ciphertext []byte) error {
+	if len(ciphertext) != kem.algDetails.LengthCiphertext {
+		return errors.New("incorrect ciphertext length")
+	}
+	return nil
+}
+
+// ValidatePublicKey checks that publicKey is a well-formed public key for the
+// signature algorithm. ML-DSA and SLH-DSA public keys have no redundancy in
+// their encoding, hence only the length is checked.
+func (sig *Signature) ValidatePublicKey(publicKey []byte) error {
+	if len(publicKey) != sig.algDetails.LengthPublicKey {
+		return errors.New("incorrect public key length")
+	}
+	return nil
+}
+
+// ValidateSignatureEncoding checks that signature is well formed for the
+// signature algorithm. For ML-DSA, the signature must have the exact length
+// mandated by FIPS 204 and a valid hint encoding (FIPS 204, Algorithm 21). For
+// SLH-DSA, the signature must have the exact length mandated by FIPS 205. For
+// the other algorithms, the signature must be non-empty and must not exceed
+// the maximum signature length.
+func (sig *Signature) ValidateSignatureEncoding(signature []byte) error {
+	if p, ok := mldsaParamSets[sig.algDetails.Name]; ok {
+		if len(signature) != p.lenSignature() {
+			return errors.New("incorrect signature size")
+		}
+		hint := signature[len(signature)-p.omega-p.k:]
+		if !mldsaHintCheck(hint, p) {
+			return errors.New("signature has a malformed hint encoding")
+		}
+		return nil
+	}
+	if isSLHDSA(sig.algDetails.Name) {
+		if len(signature) != sig.algDetails.MaxLengthSignature {
+			return errors.New("incorrect signature size")
+		}
+		return nil
+	}
+	if len(signature) == 0 ||
+		len(signature) > sig.algDetails.MaxLengthSignature {
+		return errors.New("incorrect signature size")
+	}
+	return nil
+}
+
+// validateStrict runs the public key and signature encoding checks if the sig
+// receiver is in strict mode, and does nothing otherwise.
+func (sig *Signature) validateStrict(signature []byte, publicKey []byte) error {
+	if !sig.opts.strict {
+		return nil
+	}
+	if err := sig.ValidatePublicKey(publicKey); err != nil {
+		return err
+	}
+	return sig.ValidateSignatureEncoding(signature)
+}
+
+/**************** END Validation ****************/
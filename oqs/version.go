@@ -0,0 +1,168 @@
+package oqs
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+/**************** Versions ****************/
+
+// Version is a parsed liboqs version, e.g. 0.13.1-dev.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string // e.g. "dev" or "rc1", without the leading "-"
+}
+
+// ParseVersion parses a liboqs version string, such as the one returned by
+// LiboqsVersion, of the form MAJOR.MINOR.PATCH, optionally followed by a
+// "-PRERELEASE" suffix. Build metadata following a "+" is ignored.
+func ParseVersion(text string) (Version, error) {
+	var v Version
+	core, _, _ := strings.Cut(text, "+")
+	core, v.PreRelease, _ = strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, errors.New(`invalid liboqs version "` + text + `"`)
+	}
+	for i, field := range []*int{&v.Major, &v.Minor, &v.Patch} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 {
+			return Version{}, errors.New(`invalid liboqs version "` + text +
+				`"`)
+		}
+		*field = n
+	}
+	return v, nil
+}
+
+// String returns the version string, e.g. "0.13.1-dev".
+func (v Version) String() string {
+	s := strconv.Itoa(v.Major) + "." + strconv.Itoa(v.Minor) + "." +
+		strconv.Itoa(v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// ABICompatible reports whether a binary compiled against the liboqs headers
+// of version v can use a liboqs library of version runtime, and otherwise
+// explains why in reason. liboqs does not keep its ABI, e.g. the layout of
+// OQS_KEM and OQS_SIG, stable across the minor releases of major version 0,
+// hence both versions must have the same major and minor versions, while the
+// patch versions may differ. From major version 1 on, the minor version of
+// runtime must not be lower than the one of v.
+func (v Version) ABICompatible(runtime Version) (compatible bool,
+	reason string,
+) {
+	switch {
+	case v.Major != runtime.Major:
+		return false, "the major versions differ"
+	case v.Major == 0 && v.Minor != runtime.Minor:
+		return false, "the minor versions of a 0.x release differ"
+	case runtime.Minor < v.Minor:
+		return false, "the library is older than the headers"
+	}
+	return true, ""
+}
+
+/**************** END Versions ****************/
+
+/**************** Compatibility ****************/
+
+// CompatibilityReport describes the liboqs headers the package was compiled
+// against and the liboqs library in use.
+type CompatibilityReport struct {
+	Backend string // see Backend
+	// HeaderVersion is OQS_VERSION_TEXT of the headers, and LibraryVersion is
+	// OQS_version() of the library. Both are zero for the pure-Go backend.
+	HeaderVersion  Version
+	LibraryVersion Version
+	// BuildTarget and BuildFlags describe the liboqs build, as recorded in
+	// oqsconfig.h, e.g. "x86_64-Linux-6.0" and "OQS_DIST_BUILD",
+	// "OQS_USE_OPENSSL" or "OQS_OPT_TARGET=auto".
+	BuildTarget string
+	BuildFlags  []string
+	// Compatible is true if the library can be used with the headers, see
+	// Version.ABICompatible, and otherwise Reason explains why.
+	Compatible bool
+	Reason     string
+}
+
+// CompatibilityError is returned by KeyEncapsulation.Init and Signature.Init
+// when the liboqs library in use is not compatible with the headers the
+// package was compiled against, as reading the OQS_KEM and OQS_SIG structs
+// would then return garbage.
+type CompatibilityError struct {
+	Report CompatibilityReport
+}
+
+func (e *CompatibilityError) Error() string {
+	return "liboqs-go was compiled against the liboqs " +
+		e.Report.HeaderVersion.String() + " headers, but liboqs " +
+		e.Report.LibraryVersion.String() + " is in use: " + e.Report.Reason
+}
+
+// compatibility is the report of the library in use, computed at
+// initialization and whenever a library is loaded with LoadLibrary.
+var compatibility atomic.Pointer[CompatibilityReport]
+
+func init() {
+	checkCompatibility()
+}
+
+// Compatibility returns the compatibility report of the liboqs library in use.
+func Compatibility() CompatibilityReport {
+	report := *compatibility.Load()
+	report.BuildFlags = slices.Clone(report.BuildFlags)
+	return report
+}
+
+// checkCompatibility compares the versions of the headers and of the library,
+// and stores the report.
+func checkCompatibility() {
+	report := &CompatibilityReport{
+		Backend:     backendName(),
+		BuildTarget: headerBuildTarget(),
+		BuildFlags:  headerBuildFlags(),
+		Compatible:  true,
+	}
+	defer compatibility.Store(report)
+
+	headerText, libraryText := headerVersion(), backendVersion()
+	if headerText == "" {
+		return // liboqs is not used
+	}
+	if libraryText == "" {
+		report.Compatible = false
+		report.Reason = "liboqs is not loaded"
+		return
+	}
+	var err error
+	if report.HeaderVersion, err = ParseVersion(headerText); err != nil {
+		report.Compatible, report.Reason = false, err.Error()
+		return
+	}
+	if report.LibraryVersion, err = ParseVersion(libraryText); err != nil {
+		report.Compatible, report.Reason = false, err.Error()
+		return
+	}
+	report.Compatible, report.Reason = report.HeaderVersion.ABICompatible(
+		report.LibraryVersion)
+}
+
+// compatibilityError returns a *CompatibilityError if the library in use is
+// not compatible with the headers.
+func compatibilityError() error {
+	if report := compatibility.Load(); !report.Compatible {
+		return &CompatibilityError{Report: *report}
+	}
+	return nil
+}
+
+/**************** END Compatibility ****************/
@@ -0,0 +1,121 @@
+package oqstests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestCanonicalNames tests the resolution of historical algorithm names.
+func TestCanonicalNames(t *testing.T) {
+	kemTests := map[string]string{
+		"Kyber768":   "ML-KEM-768",
+		"ML-KEM-768": "ML-KEM-768",
+		"BIKE-L1":    "BIKE-L1",
+	}
+	for name, expected := range kemTests {
+		if canonical := oqs.CanonicalKEMName(name); canonical != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, canonical)
+		}
+	}
+	sigTests := map[string]string{
+		"Dilithium3":                  "ML-DSA-65",
+		"SPHINCS+-SHA256-128f-simple": "SLH_DSA_PURE_SHA2_128F",
+		"SPHINCS+-SHAKE-256s-simple":  "SLH_DSA_PURE_SHAKE_256S",
+		"Falcon-512":                  "Falcon-512",
+	}
+	for name, expected := range sigTests {
+		if canonical := oqs.CanonicalSigName(name); canonical != expected {
+			t.Errorf("%s: expected %s, got %s", name, expected, canonical)
+		}
+	}
+}
+
+// TestKeyMaterialReusable tests the key reuse helper on renames, on
+// superseded algorithms and on unrelated algorithms.
+func TestKeyMaterialReusable(t *testing.T) {
+	tests := []struct {
+		oldName, newName string
+		reusable         bool
+	}{
+		{"ML-KEM-768", "ML-KEM-768", true},
+		{"SPHINCS+-SHAKE256-128f-simple", "SPHINCS+-SHAKE-128f-simple", true},
+		{"SPHINCS+-SHAKE256-128f-simple", "SLH_DSA_PURE_SHAKE_128F", false},
+		// SPHINCS+ 3.1 changed the SHA-2 instantiation along with the rename
+		{"SPHINCS+-SHA256-128f-simple", "SPHINCS+-SHA2-128f-simple", false},
+		{"SPHINCS+-SHA256-256s-simple", "SPHINCS+-SHA2-256s-simple", false},
+		{"Kyber768", "ML-KEM-768", false},
+		{"Dilithium2", "ML-DSA-65", false},
+		{"ML-KEM-768", "ML-KEM-1024", false},
+	}
+	for _, tt := range tests {
+		reusable, reason := oqs.KeyMaterialReusable(tt.oldName, tt.newName)
+		if reusable != tt.reusable || (reason == "") != tt.reusable {
+			t.Errorf("%s -> %s: expected %v, got %v (%s)", tt.oldName,
+				tt.newName, tt.reusable, reusable, reason)
+		}
+	}
+}
+
+// TestInitAliases tests that Init reports historical names to the
+// deprecation handler, resolves wire-compatible renames, and hints at the
+// successor of unsupported names.
+func TestInitAliases(t *testing.T) {
+	var warnings []oqs.DeprecationWarning
+	oqs.SetDeprecationHandler(func(w oqs.DeprecationWarning) {
+		warnings = append(warnings, w)
+	})
+	defer oqs.SetDeprecationHandler(nil)
+
+	var sig oqs.Signature
+	defer sig.Clean()
+	err := sig.Init("SPHINCS+-SHAKE256-128f-simple", nil)
+	if oqs.IsSigEnabled("SPHINCS+-SHAKE-128f-simple") {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name := sig.Details().Name; name != "SPHINCS+-SHAKE-128f-simple" {
+			t.Errorf("resolved to %s", name)
+		}
+	} else if err == nil {
+		t.Error("unsupported algorithm initialized")
+	}
+	if len(warnings) != 1 || warnings[0].CurrentName !=
+		"SLH_DSA_PURE_SHAKE_128F" || !warnings[0].Resolved {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+
+	// The SHA256 parameter sets are not silently replaced by the
+	// incompatible SHA2 ones
+	warnings = nil
+	var sha256Sig oqs.Signature
+	defer sha256Sig.Clean()
+	err = sha256Sig.Init("SPHINCS+-SHA256-128f-simple", nil)
+	if !oqs.IsSigEnabled("SPHINCS+-SHA256-128f-simple") && err == nil {
+		t.Errorf("resolved to %s", sha256Sig.Details().Name)
+	}
+	if len(warnings) != 1 || warnings[0].Resolved ||
+		!strings.Contains(warnings[0].Message, "regenerate") {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+
+	warnings = nil
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	err = kem.Init("Kyber768", nil)
+	switch {
+	case oqs.IsKEMEnabled("Kyber768"):
+		if err != nil {
+			t.Error(err)
+		}
+	case !oqs.IsKEMSupported("Kyber768"):
+		if err == nil || !strings.Contains(err.Error(), `"ML-KEM-768"`) {
+			t.Errorf("expected a hint at ML-KEM-768, got %v", err)
+		}
+	}
+	if len(warnings) != 1 || warnings[0].Resolved ||
+		!strings.Contains(warnings[0].Message, "regenerate") {
+		t.Errorf("unexpected warnings %+v", warnings)
+	}
+}
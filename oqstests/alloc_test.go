@@ -0,0 +1,261 @@
+package oqstests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// benchKEMName and benchSigName return the algorithms used by the
+// allocation-free tests and benchmarks, and skip tb if there is none.
+func benchKEMName(tb testing.TB) string {
+	if oqs.IsKEMEnabled("ML-KEM-768") {
+		return "ML-KEM-768"
+	}
+	return firstKEMName(tb)
+}
+
+func benchSigName(tb testing.TB) string {
+	if oqs.IsSigEnabled("ML-DSA-65") {
+		return "ML-DSA-65"
+	}
+	return firstSigName(tb)
+}
+
+// firstKEMName returns the first enabled KEM algorithm, and skips tb if there
+// is none, as without cgo.
+func firstKEMName(tb testing.TB) string {
+	tb.Helper()
+	kems := oqs.EnabledKEMs()
+	if len(kems) == 0 {
+		tb.Skip("no KEM algorithm is enabled")
+	}
+	return kems[0]
+}
+
+// firstSigName returns the first enabled signature algorithm, and skips tb if
+// there is none, as without cgo.
+func firstSigName(tb testing.TB) string {
+	tb.Helper()
+	sigs := oqs.EnabledSigs()
+	if len(sigs) == 0 {
+		tb.Skip("no signature algorithm is enabled")
+	}
+	return sigs[0]
+}
+
+// TestKEMBufferVariants tests GenerateKeyPairInto, EncapSecretTo and
+// DecapSecretTo, including their length checks.
+func TestKEMBufferVariants(t *testing.T) {
+	var client, server oqs.KeyEncapsulation
+	defer client.Clean()
+	defer server.Clean()
+	_ = client.Init(benchKEMName(t), nil)
+	_ = server.Init(benchKEMName(t), nil)
+	details := client.Details()
+	publicKey := make([]byte, details.LengthPublicKey)
+	ciphertext := make([]byte, details.LengthCiphertext)
+	sharedSecretServer := make([]byte, details.LengthSharedSecret)
+	sharedSecretClient := make([]byte, details.LengthSharedSecret)
+
+	if err := client.GenerateKeyPairInto(publicKey[1:]); err == nil {
+		t.Error("short public key buffer accepted")
+	}
+	if err := client.GenerateKeyPairInto(publicKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.EncapSecretTo(ciphertext[1:], sharedSecretServer,
+		publicKey); err == nil {
+		t.Error("short ciphertext buffer accepted")
+	}
+	if err := server.EncapSecretTo(ciphertext, sharedSecretServer,
+		publicKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DecapSecretTo(sharedSecretClient[1:],
+		ciphertext); err == nil {
+		t.Error("short shared secret buffer accepted")
+	}
+	if err := client.DecapSecretTo(sharedSecretClient, ciphertext); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sharedSecretClient, sharedSecretServer) {
+		t.Error("shared secrets do not coincide")
+	}
+}
+
+// TestSignAppend tests that SignAppend appends a valid signature of exact
+// length to dst.
+func TestSignAppend(t *testing.T) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(t), nil)
+	publicKey := make([]byte, signer.Details().LengthPublicKey)
+	if err := signer.GenerateKeyPairInto(publicKey); err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("This is our favourite message to sign")
+	prefix := []byte("prefix")
+	buf, err := signer.SignAppend(append([]byte(nil), prefix...), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf, prefix) {
+		t.Fatal("dst was not preserved")
+	}
+	signature := buf[len(prefix):]
+	if len(signature) > signer.Details().MaxLengthSignature {
+		t.Fatalf("signature of length %d", len(signature))
+	}
+	if isValid, _ := signer.Verify(msg, signature, publicKey); !isValid {
+		t.Error("signature verification failed")
+	}
+}
+
+// TestSignExactCapacity tests that the signing functions returning a new
+// signature do not keep a maximum-length buffer alive, and return a nil
+// signature on error.
+func TestSignExactCapacity(t *testing.T) {
+	msg := []byte("This is our favourite message to sign")
+	for _, sigName := range oqs.EnabledSigs() {
+		var signer oqs.Signature
+		_ = signer.Init(sigName, nil)
+		if _, err := signer.GenerateKeyPair(); err != nil {
+			t.Fatalf("%s: %v", sigName, err)
+		}
+		sign := map[string]func() ([]byte, error){
+			"Sign": func() ([]byte, error) { return signer.Sign(msg) },
+		}
+		if signer.Details().SigWithCtxSupport {
+			sign["SignWithCtxStr"] = func() ([]byte, error) {
+				return signer.SignWithCtxStr(msg, []byte("context"))
+			}
+		}
+		if strings.HasPrefix(sigName, "ML-DSA") {
+			sign["SignWithCtxDeterministic"] = func() ([]byte, error) {
+				return signer.SignWithCtxDeterministic(msg, nil)
+			}
+		}
+		for name, fn := range sign {
+			signature, err := fn()
+			if err != nil {
+				t.Errorf("%s: %s: %v", sigName, name, err)
+			} else if cap(signature) != len(signature) {
+				t.Errorf("%s: %s returned a signature of length %d and "+
+					"capacity %d", sigName, name, len(signature),
+					cap(signature))
+			}
+		}
+		signer.Clean()
+		for name, fn := range sign {
+			if signature, err := fn(); err == nil || signature != nil {
+				t.Errorf("%s: %s after Clean returned %x, %v", sigName, name,
+					signature, err)
+			}
+		}
+	}
+}
+
+// TestBufferVariantsAllocations tests that the buffer variants do not
+// allocate with the liboqs backend.
+func TestBufferVariantsAllocations(t *testing.T) {
+	if oqs.Backend() != "liboqs" {
+		t.Skip("only the liboqs backend is allocation-free")
+	}
+	var kem oqs.KeyEncapsulation
+	var signer oqs.Signature
+	defer kem.Clean()
+	defer signer.Clean()
+	_ = kem.Init(benchKEMName(t), nil)
+	_ = signer.Init(benchSigName(t), nil)
+	kemDetails := kem.Details()
+	publicKey := make([]byte, kemDetails.LengthPublicKey)
+	ciphertext := make([]byte, kemDetails.LengthCiphertext)
+	sharedSecret := make([]byte, kemDetails.LengthSharedSecret)
+	sigPublicKey := make([]byte, signer.Details().LengthPublicKey)
+	_ = kem.GenerateKeyPairInto(publicKey)
+	_ = signer.GenerateKeyPairInto(sigPublicKey)
+	msg := []byte("This is our favourite message to sign")
+	buf, _ := signer.SignAppend(nil, msg)
+
+	for name, f := range map[string]func(){
+		"GenerateKeyPairInto": func() { _ = kem.GenerateKeyPairInto(publicKey) },
+		"EncapSecretTo": func() {
+			_ = kem.EncapSecretTo(ciphertext, sharedSecret, publicKey)
+		},
+		"DecapSecretTo": func() { _ = kem.DecapSecretTo(sharedSecret, ciphertext) },
+		"SignAppend":    func() { buf, _ = signer.SignAppend(buf[:0], msg) },
+	} {
+		if allocs := testing.AllocsPerRun(100, f); allocs != 0 {
+			t.Errorf("%s: %v allocations per call", name, allocs)
+		}
+	}
+}
+
+func BenchmarkGenerateKeyPairInto(b *testing.B) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(benchKEMName(b), nil)
+	publicKey := make([]byte, kem.Details().LengthPublicKey)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := kem.GenerateKeyPairInto(publicKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncapSecretTo(b *testing.B) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(benchKEMName(b), nil)
+	details := kem.Details()
+	publicKey := make([]byte, details.LengthPublicKey)
+	ciphertext := make([]byte, details.LengthCiphertext)
+	sharedSecret := make([]byte, details.LengthSharedSecret)
+	_ = kem.GenerateKeyPairInto(publicKey)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := kem.EncapSecretTo(ciphertext, sharedSecret,
+			publicKey); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecapSecretTo(b *testing.B) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(benchKEMName(b), nil)
+	details := kem.Details()
+	publicKey := make([]byte, details.LengthPublicKey)
+	ciphertext := make([]byte, details.LengthCiphertext)
+	sharedSecret := make([]byte, details.LengthSharedSecret)
+	_ = kem.GenerateKeyPairInto(publicKey)
+	_ = kem.EncapSecretTo(ciphertext, sharedSecret, publicKey)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := kem.DecapSecretTo(sharedSecret, ciphertext); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignAppend(b *testing.B) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(b), nil)
+	publicKey := make([]byte, signer.Details().LengthPublicKey)
+	_ = signer.GenerateKeyPairInto(publicKey)
+	msg := []byte("This is our favourite message to sign")
+	buf, _ := signer.SignAppend(nil, msg)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = signer.SignAppend(buf[:0], msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package oqstests
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// batchMessages returns n distinct messages.
+func batchMessages(n int) [][]byte {
+	messages := make([][]byte, n)
+	for i := range messages {
+		messages[i] = []byte(fmt.Sprintf("log record #%d", i))
+	}
+	return messages
+}
+
+// TestSignBatch tests that SignBatch returns valid signatures in order, for
+// several degrees of parallelism.
+func TestSignBatch(t *testing.T) {
+	for _, parallelism := range []int{0, 1, 3, 64} {
+		var signer oqs.Signature
+		_ = signer.Init(benchSigName(t), nil,
+			oqs.WithBatchParallelism(parallelism))
+		publicKey, _ := signer.GenerateKeyPair()
+		messages := batchMessages(17)
+		signatures, errs := signer.SignBatch(messages)
+		if len(signatures) != len(messages) || len(errs) != len(messages) {
+			t.Fatalf("parallelism %d: got %d signatures and %d errors",
+				parallelism, len(signatures), len(errs))
+		}
+		for i := range messages {
+			if errs[i] != nil {
+				t.Fatalf("parallelism %d: %v", parallelism, errs[i])
+			}
+			isValid, err := signer.Verify(messages[i], signatures[i],
+				publicKey)
+			if err != nil || !isValid {
+				t.Errorf("parallelism %d: invalid signature of message %d",
+					parallelism, i)
+			}
+		}
+		signer.Clean()
+	}
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(t), nil)
+	signatures, errs := signer.SignBatch(batchMessages(2))
+	for i := range errs {
+		if errs[i] == nil || signatures[i] != nil {
+			t.Error("signed without a secret key")
+		}
+	}
+	if signatures, errs := signer.SignBatch(nil); len(signatures) != 0 ||
+		len(errs) != 0 {
+		t.Error("empty batch returned results")
+	}
+}
+
+// TestVerifyBatch tests that VerifyBatch reports valid signatures, invalid
+// signatures and malformed items in order.
+func TestVerifyBatch(t *testing.T) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(t), nil, oqs.WithBatchParallelism(2))
+	publicKey, _ := signer.GenerateKeyPair()
+	messages := batchMessages(8)
+	signatures, _ := signer.SignBatch(messages)
+
+	items := make([]oqs.VerifyItem, len(messages))
+	for i := range items {
+		items[i] = oqs.VerifyItem{Message: messages[i],
+			Signature: signatures[i], PublicKey: publicKey}
+	}
+	items[3].Message = []byte("tampered")
+	items[5].PublicKey = publicKey[1:]
+	valid, errs := signer.VerifyBatch(items)
+	for i := range items {
+		switch i {
+		case 3:
+			if valid[i] || errs[i] != nil {
+				t.Errorf("tampered item %d: valid %v, error %v", i, valid[i],
+					errs[i])
+			}
+		case 5:
+			if valid[i] || errs[i] == nil {
+				t.Errorf("malformed item %d accepted", i)
+			}
+		default:
+			if !valid[i] || errs[i] != nil {
+				t.Errorf("item %d: valid %v, error %v", i, valid[i], errs[i])
+			}
+		}
+	}
+
+	var uninitialized oqs.Signature
+	if _, errs := uninitialized.VerifyBatch(items[:1]); errs[0] == nil {
+		t.Error("uninitialized signature verified a batch")
+	}
+}
+
+// BenchmarkSignSequential and BenchmarkSignBatch compare signing 256 messages
+// in a loop and as a batch.
+func BenchmarkSignSequential(b *testing.B) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(b), nil)
+	_, _ = signer.GenerateKeyPair()
+	messages := batchMessages(256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, message := range messages {
+			if _, err := signer.Sign(message); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSignBatch(b *testing.B) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(b), nil)
+	_, _ = signer.GenerateKeyPair()
+	messages := batchMessages(256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, errs := signer.SignBatch(messages); errs[0] != nil {
+			b.Fatal(errs[0])
+		}
+	}
+}
+
+// BenchmarkVerifySequential and BenchmarkVerifyBatch compare verifying 256
+// signatures in a loop and as a batch.
+func BenchmarkVerifySequential(b *testing.B) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(b), nil)
+	items := benchVerifyItems(&signer, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			if isValid, _ := signer.Verify(item.Message, item.Signature,
+				item.PublicKey); !isValid {
+				b.Fatal("invalid signature")
+			}
+		}
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(benchSigName(b), nil)
+	items := benchVerifyItems(&signer, 256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if valid, _ := signer.VerifyBatch(items); !valid[0] {
+			b.Fatal("invalid signature")
+		}
+	}
+}
+
+// benchVerifyItems generates a key pair with signer and returns n signed
+// messages to verify.
+func benchVerifyItems(signer *oqs.Signature, n int) []oqs.VerifyItem {
+	publicKey, _ := signer.GenerateKeyPair()
+	messages := batchMessages(n)
+	signatures, _ := signer.SignBatch(messages)
+	items := make([]oqs.VerifyItem, n)
+	for i := range items {
+		items[i] = oqs.VerifyItem{Message: messages[i],
+			Signature: signatures[i], PublicKey: bytes.Clone(publicKey)}
+	}
+	return items
+}
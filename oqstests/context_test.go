@@ -0,0 +1,189 @@
+package oqstests
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// blockingReader blocks every read until release is closed.
+type blockingReader struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingReader() *blockingReader {
+	return &blockingReader{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+}
+
+func (r *blockingReader) Read(b []byte) (int, error) {
+	select {
+	case r.started <- struct{}{}:
+	default:
+	}
+	<-r.release
+	return rand.Read(b)
+}
+
+// TestWorkerPoolSize tests the worker pool sizing.
+func TestWorkerPoolSize(t *testing.T) {
+	size := oqs.WorkerPoolSize()
+	defer func() { _ = oqs.SetWorkerPoolSize(size) }()
+	if size < 1 {
+		t.Errorf("invalid default worker pool size %d", size)
+	}
+	if err := oqs.SetWorkerPoolSize(0); err == nil {
+		t.Error("empty worker pool accepted")
+	}
+	if err := oqs.SetWorkerPoolSize(3); err != nil ||
+		oqs.WorkerPoolSize() != 3 {
+		t.Errorf("can not resize the worker pool: %v", err)
+	}
+}
+
+// TestContextVariants tests the context-aware methods with a context that is
+// never done, and with a cancelled one.
+func TestContextVariants(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	var client, server oqs.KeyEncapsulation
+	defer client.Clean()
+	defer server.Clean()
+	_ = client.Init(firstKEMName(t), nil)
+	_ = server.Init(firstKEMName(t), nil)
+	publicKey, err := client.GenerateKeyPairContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecretServer, err := server.EncapSecretContext(ctx,
+		publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedSecretClient, err := client.DecapSecretContext(ctx, ciphertext)
+	if err != nil || !bytes.Equal(sharedSecretClient, sharedSecretServer) {
+		t.Errorf("shared secrets do not coincide: %v", err)
+	}
+	if _, err := client.DecapSecretContext(cancelled,
+		ciphertext); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil)
+	sigPublicKey, err := signer.GenerateKeyPairContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("This is our favourite message to sign")
+	signature, err := signer.SignContext(ctx, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isValid, err := signer.VerifyContext(ctx, msg, signature, sigPublicKey)
+	if err != nil || !isValid {
+		t.Errorf("signature verification failed: %v", err)
+	}
+	if _, err := signer.SignContext(cancelled,
+		msg); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// TestContextCancellation tests that a blocked operation is abandoned
+// promptly, that its result is discarded, and that the worker pool bounds the
+// number of concurrent operations.
+func TestContextCancellation(t *testing.T) {
+	size := oqs.WorkerPoolSize()
+	defer func() { _ = oqs.SetWorkerPoolSize(size) }()
+	_ = oqs.SetWorkerPoolSize(1)
+
+	r := newBlockingReader()
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(firstKEMName(t), nil, oqs.WithRandomReader(r))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := kem.GenerateKeyPairContext(ctx)
+		done <- err
+	}()
+	<-r.started
+
+	// The single worker is busy
+	timeout, cancelTimeout := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancelTimeout()
+	var other oqs.Signature
+	defer other.Clean()
+	_ = other.Init(firstSigName(t), nil)
+	if _, err := other.GenerateKeyPairContext(
+		timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cancellation was not prompt")
+	}
+	close(r.release)
+	if kem.ExportSecretKey() != nil {
+		t.Error("the secret key of an abandoned key generation was kept")
+	}
+}
+
+// TestContextCleanCopy tests that cleaning a copy made before the first
+// context operation waits for the operations abandoned by the original.
+func TestContextCleanCopy(t *testing.T) {
+	r := newBlockingReader()
+	var kem, dup oqs.KeyEncapsulation
+	defer kem.Clean()
+	if err := kem.Init(firstKEMName(t), nil,
+		oqs.WithRandomReader(r)); err != nil {
+		t.Fatal(err)
+	}
+	duplicate(&dup, &kem)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := kem.GenerateKeyPairContext(ctx)
+		done <- err
+	}()
+	<-r.started
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	cleaned := make(chan struct{})
+	go func() {
+		defer close(cleaned)
+		dup.Clean()
+	}()
+	select {
+	case <-cleaned:
+		t.Error("Clean on a copy returned while an abandoned operation " +
+			"is in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(r.release)
+	<-cleaned
+}
@@ -0,0 +1,67 @@
+package oqstests
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestCPUFeatures tests the CPU features detected by liboqs.
+func TestCPUFeatures(t *testing.T) {
+	features := oqs.CPUFeatures()
+	if oqs.Backend() != "liboqs" {
+		if len(features) != 0 {
+			t.Errorf("CPU features reported without liboqs: %v", features)
+		}
+		return
+	}
+	if _, ok := features["avx2"]; !ok {
+		t.Error("avx2 is not reported")
+	}
+	// SSE2 is part of the amd64 baseline
+	if runtime.GOARCH == "amd64" && !features["sse2"] {
+		t.Error("sse2 is not detected")
+	}
+}
+
+// TestAlgorithmImplementation tests the implementation reporting and the
+// WithPortableImplementation option.
+func TestAlgorithmImplementation(t *testing.T) {
+	if _, err := oqs.AlgorithmImplementation("no-such-alg"); err == nil {
+		t.Error("implementation reported for an unknown algorithm")
+	}
+	features := oqs.CPUFeatures()
+	for _, kemName := range oqs.EnabledKEMs() {
+		impl, err := oqs.AlgorithmImplementation(kemName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case oqs.Backend() == "purego":
+			if impl.Variant != oqs.ImplementationPureGo {
+				t.Errorf("%s: variant %q with the pure-Go backend", kemName,
+					impl.Variant)
+			}
+		case impl.Portable():
+			if len(impl.Features) != 0 {
+				t.Errorf("%s: portable variant relies on %v", kemName,
+					impl.Features)
+			}
+		default:
+			for _, feature := range impl.Features {
+				if _, ok := features[feature]; !ok {
+					t.Errorf("%s: unknown CPU feature %q", kemName, feature)
+				}
+			}
+		}
+
+		var kem oqs.KeyEncapsulation
+		err = kem.Init(kemName, nil, oqs.WithPortableImplementation())
+		if (err == nil) != impl.Portable() {
+			t.Errorf("%s: Init with the portable variant required: %v, "+
+				"variant %q", kemName, err, impl.Variant)
+		}
+		kem.Clean()
+	}
+}
@@ -0,0 +1,190 @@
+//go:build go1.24
+
+package oqstests
+
+import (
+	"bytes"
+	"crypto/sha3"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// mldsaAccumulated lists the expected results of the accumulated deterministic
+// ML-DSA test vectors, as used by the crypto/mldsa tests of the Go standard
+// library: 100 key pairs are generated from seeds read from SHAKE128(""), and
+// their public keys and deterministic signatures of the empty message are
+// absorbed into a second SHAKE128 instance, whose first 32 bytes of output are
+// compared against the expected value.
+var mldsaAccumulated = map[string]string{
+	"ML-DSA-44": "d51148e1f9f4fa1a723a6cf42e25f2a99eb5c1b378b3d2dbbd561b1203beeae4",
+	"ML-DSA-65": "8358a1843220194417cadbc2651295cd8fc65125b5a5c1a239a16dc8b57ca199",
+	"ML-DSA-87": "8c3ad714777622b8f21ce31bb35f71394f23bc0fcf3c78ace5d608990f3b061b",
+}
+
+// TestSignatureDeterministicVectors checks deterministic ML-DSA signing against
+// the accumulated test vectors.
+func TestSignatureDeterministicVectors(t *testing.T) {
+	for sigName, expected := range mldsaAccumulated {
+		if !oqs.IsSigEnabled(sigName) {
+			continue
+		}
+		seeds := sha3.NewSHAKE128()
+		accumulated := sha3.NewSHAKE128()
+		seed := make([]byte, 32)
+		for i := 0; i < 100; i++ {
+			_, _ = seeds.Read(seed)
+			var signer oqs.Signature
+			// Ignore potential errors everywhere
+			_ = signer.Init(sigName, nil,
+				oqs.WithRandomReader(bytes.NewReader(seed)))
+			publicKey, _ := signer.GenerateKeyPair()
+			signature, err := signer.SignDeterministic(nil)
+			signer.Clean()
+			if err != nil {
+				t.Fatalf("%s: %v", sigName, err)
+			}
+			_, _ = accumulated.Write(publicKey)
+			_, _ = accumulated.Write(signature)
+		}
+		sum := make([]byte, 32)
+		_, _ = accumulated.Read(sum)
+		if got := hex.EncodeToString(sum); got != expected {
+			t.Errorf("%s: got %s, expected %s", sigName, got, expected)
+		}
+	}
+}
+
+// TestSignatureDeterministic tests that deterministic signatures are
+// reproducible and valid, and that they are rejected for algorithms without a
+// deterministic variant.
+func TestSignatureDeterministic(t *testing.T) {
+	msg := []byte("This is our favourite message to sign")
+	context := []byte("context")
+	for _, sigName := range oqs.EnabledSigs() {
+		var signer oqs.Signature
+		// Ignore potential errors everywhere
+		_ = signer.Init(sigName, nil)
+		publicKey, _ := signer.GenerateKeyPair()
+		signature, err := signer.SignWithCtxDeterministic(msg, context)
+		if _, ok := mldsaAccumulated[sigName]; !ok {
+			if err == nil {
+				t.Errorf("%s: deterministic signing should have failed",
+					sigName)
+			}
+			signer.Clean()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", sigName, err)
+		}
+		again, _ := signer.SignWithCtxDeterministic(msg, context)
+		if !bytes.Equal(signature, again) {
+			t.Errorf("%s: deterministic signatures differ", sigName)
+		}
+		withoutContext, _ := signer.SignDeterministic(msg)
+		if bytes.Equal(signature, withoutContext) {
+			t.Errorf("%s: the context string is ignored", sigName)
+		}
+		isValid, _ := signer.VerifyWithCtxStr(msg, signature, context,
+			publicKey)
+		if !isValid {
+			t.Errorf("%s: deterministic signature verification failed",
+				sigName)
+		}
+		if _, err := signer.SignWithCtxDeterministic(msg,
+			make([]byte, oqs.MaxLengthContext+1)); err == nil {
+			t.Errorf("%s: context string too long accepted", sigName)
+		}
+		signer.Clean()
+	}
+}
+
+// TestSignatureDeterministicConcurrent tests that deterministic signing does
+// not leak its zero randomness into concurrent hedged signing with the same
+// object.
+func TestSignatureDeterministicConcurrent(t *testing.T) {
+	sigName := "ML-DSA-44"
+	if !oqs.IsSigEnabled(sigName) {
+		t.Skip(sigName + " is not enabled")
+	}
+	msg := []byte("This is our favourite message to sign")
+	var signer oqs.Signature
+	defer signer.Clean()
+	if err := signer.Init(sigName, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.GenerateKeyPair(); err != nil {
+		t.Fatal(err)
+	}
+	deterministic, err := signer.SignDeterministic(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const numSigners = 4
+	var wg sync.WaitGroup
+	for i := 0; i < numSigners; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := signer.SignDeterministic(msg); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				hedged, err := signer.Sign(msg)
+				if err != nil {
+					t.Error(err)
+				} else if bytes.Equal(hedged, deterministic) {
+					t.Error("hedged signing used the zero randomness")
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestSignatureDeterministicRandomAlgorithm tests that deterministic signing
+// fails with a clear error while the "OpenSSL" RNG algorithm is selected with
+// the liboqs backend, and works again once switched back to "system".
+func TestSignatureDeterministicRandomAlgorithm(t *testing.T) {
+	sigName := "ML-DSA-44"
+	if !oqs.IsSigEnabled(sigName) {
+		t.Skip(sigName + " is not enabled")
+	}
+	if oqs.Backend() != "liboqs" ||
+		!slices.Contains(oqs.Compatibility().BuildFlags, "OQS_USE_OPENSSL") {
+		t.Skip("the OpenSSL RNG algorithm is not available")
+	}
+	msg := []byte("This is our favourite message to sign")
+	var signer oqs.Signature
+	defer signer.Clean()
+	if err := signer.Init(sigName, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := signer.GenerateKeyPair(); err != nil {
+		t.Fatal(err)
+	}
+	if err := oqs.RandomBytesSwitchAlgorithm("OpenSSL"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := signer.SignDeterministic(msg)
+	if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+		t.Fatal(err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "deterministic signing") {
+		t.Errorf("expected a deterministic signing error, got %v", err)
+	}
+	if _, err := signer.SignDeterministic(msg); err != nil {
+		t.Errorf("after switching back to system: %v", err)
+	}
+}
@@ -0,0 +1,312 @@
+//go:build !oqs_purego && go1.27
+
+package oqstests
+
+import (
+	"bytes"
+	"crypto/mldsa"
+	"crypto/mlkem"
+	"crypto/mlkem/mlkemtest"
+	"crypto/rand"
+	"crypto/sha3"
+	"encoding/hex"
+	"flag"
+	"log"
+	mathrand "math/rand/v2"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// The differential tests compare liboqs against the independent ML-KEM and
+// ML-DSA implementations of the Go standard library. liboqs is derandomized
+// by feeding it the seeds through a custom RNG algorithm, so that both sides
+// operate on identical inputs. All seeds are derived from a master seed, which
+// is printed on failure and can be passed back with -diffseed to reproduce a
+// divergence.
+
+// diffSeed is the hex-encoded 32-byte master seed of the differential tests.
+var diffSeed = flag.String("diffseed", "",
+	"hex-encoded 32-byte master seed for the differential tests")
+
+// differentialIterations is the number of seeds tried per algorithm.
+const differentialIterations = 16
+
+// seedQueue is a custom RNG algorithm that hands out preloaded bytes, and
+// records an overrun if liboqs asks for more randomness than expected.
+type seedQueue struct {
+	buf     []byte
+	overrun bool
+}
+
+// load replaces the content of the queue.
+func (q *seedQueue) load(seeds ...[]byte) {
+	q.buf = bytes.Join(seeds, nil)
+	q.overrun = false
+}
+
+// read implements the RNG algorithm callback.
+func (q *seedQueue) read(randomArray []byte, bytesToRead int) {
+	n := copy(randomArray[:bytesToRead], q.buf)
+	q.buf = q.buf[n:]
+	if n < bytesToRead {
+		q.overrun = true
+		clear(randomArray[n:bytesToRead])
+	}
+}
+
+// exhausted returns true if liboqs consumed exactly the preloaded bytes.
+func (q *seedQueue) exhausted() bool {
+	return len(q.buf) == 0 && !q.overrun
+}
+
+// newDiffRNG returns the seed generator of the differential tests, together
+// with its hex-encoded master seed.
+func newDiffRNG(t *testing.T) (*mathrand.ChaCha8, string) {
+	var master [32]byte
+	if *diffSeed != "" {
+		decoded, err := hex.DecodeString(*diffSeed)
+		if err != nil || len(decoded) != len(master) {
+			t.Fatalf("-diffseed must be %d hex-encoded bytes", len(master))
+		}
+		copy(master[:], decoded)
+	} else {
+		_, _ = rand.Read(master[:])
+	}
+	return mathrand.NewChaCha8(master), hex.EncodeToString(master[:])
+}
+
+// useSeedQueue installs q as the liboqs RNG algorithm and returns a function
+// restoring the default one.
+func useSeedQueue(t *testing.T, q *seedQueue) func() {
+	if err := oqs.RandomBytesCustomAlgorithm(q.read); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// goMLKEM wraps the parameter sets of crypto/mlkem behind a common API.
+type goMLKEM struct {
+	k       int
+	keyGen  func(seed []byte) (ek []byte, decaps func([]byte) ([]byte, error))
+	encaps  func(ek, m []byte) (ss, ct []byte, err error)
+	lenSeed int
+}
+
+// goMLKEMs lists the ML-KEM parameter sets implemented by crypto/mlkem.
+var goMLKEMs = map[string]goMLKEM{
+	"ML-KEM-768": {
+		k: 3,
+		keyGen: func(seed []byte) ([]byte, func([]byte) ([]byte, error)) {
+			dk, _ := mlkem.NewDecapsulationKey768(seed)
+			return dk.EncapsulationKey().Bytes(), dk.Decapsulate
+		},
+		encaps: func(ek, m []byte) ([]byte, []byte, error) {
+			key, err := mlkem.NewEncapsulationKey768(ek)
+			if err != nil {
+				return nil, nil, err
+			}
+			return mlkemtest.Encapsulate768(key, m)
+		},
+		lenSeed: mlkem.SeedSize,
+	},
+	"ML-KEM-1024": {
+		k: 4,
+		keyGen: func(seed []byte) ([]byte, func([]byte) ([]byte, error)) {
+			dk, _ := mlkem.NewDecapsulationKey1024(seed)
+			return dk.EncapsulationKey().Bytes(), dk.Decapsulate
+		},
+		encaps: func(ek, m []byte) ([]byte, []byte, error) {
+			key, err := mlkem.NewEncapsulationKey1024(ek)
+			if err != nil {
+				return nil, nil, err
+			}
+			return mlkemtest.Encapsulate1024(key, m)
+		},
+		lenSeed: mlkem.SeedSize,
+	},
+}
+
+// goMLDSAs lists the ML-DSA parameter sets implemented by crypto/mldsa.
+var goMLDSAs = map[string]func() mldsa.Parameters{
+	"ML-DSA-44": mldsa.MLDSA44,
+	"ML-DSA-65": mldsa.MLDSA65,
+	"ML-DSA-87": mldsa.MLDSA87,
+}
+
+// testKEMDifferential compares a single derandomized ML-KEM run of liboqs
+// against crypto/mlkem, and returns a description of the first divergence, or
+// an empty string if the results are bit-identical.
+func testKEMDifferential(kemName string, ref goMLKEM, seed, m []byte,
+	q *seedQueue,
+) string {
+	var client, server oqs.KeyEncapsulation
+	defer client.Clean()
+	defer server.Clean()
+	// Ignore potential errors everywhere
+	_ = client.Init(kemName, nil)
+	_ = server.Init(kemName, nil)
+
+	q.load(seed)
+	publicKey, _ := client.GenerateKeyPair()
+	if !q.exhausted() {
+		return "keypair did not consume exactly d || z"
+	}
+	refPublicKey, refDecaps := ref.keyGen(seed)
+	if !bytes.Equal(publicKey, refPublicKey) {
+		return "encapsulation keys differ"
+	}
+	// FIPS 203 expanded decapsulation key dk_PKE || ek || H(ek) || z
+	secretKey := client.ExportSecretKey()
+	lenDK := 384 * ref.k
+	hash := sha3.Sum256(refPublicKey)
+	if !bytes.Equal(secretKey[lenDK:lenDK+len(refPublicKey)], refPublicKey) ||
+		!bytes.Equal(secretKey[len(secretKey)-64:len(secretKey)-32], hash[:]) ||
+		!bytes.Equal(secretKey[len(secretKey)-32:], seed[32:]) {
+		return "decapsulation key is not ek || H(ek) || z suffixed"
+	}
+
+	q.load(m)
+	ciphertext, sharedSecret, _ := server.EncapSecret(publicKey)
+	if !q.exhausted() {
+		return "encaps did not consume exactly m"
+	}
+	refSharedSecret, refCiphertext, err := ref.encaps(refPublicKey, m)
+	if err != nil {
+		return "crypto/mlkem rejected the encapsulation key: " + err.Error()
+	}
+	if !bytes.Equal(ciphertext, refCiphertext) {
+		return "ciphertexts differ"
+	}
+	if !bytes.Equal(sharedSecret, refSharedSecret) {
+		return "encapsulated shared secrets differ"
+	}
+
+	decapsulated, _ := client.DecapSecret(ciphertext)
+	if !bytes.Equal(decapsulated, refSharedSecret) {
+		return "decapsulated shared secrets differ"
+	}
+
+	// Implicit rejection must agree as well
+	wrongCiphertext := bytes.Clone(ciphertext)
+	wrongCiphertext[0] ^= 0x01
+	rejected, _ := client.DecapSecret(wrongCiphertext)
+	refRejected, _ := refDecaps(wrongCiphertext)
+	if !bytes.Equal(rejected, refRejected) {
+		return "implicit rejection shared secrets differ"
+	}
+	return ""
+}
+
+// TestKeyEncapsulationDifferential compares liboqs ML-KEM against
+// crypto/mlkem for keys, ciphertexts and shared secrets.
+func TestKeyEncapsulationDifferential(t *testing.T) {
+	gen, master := newDiffRNG(t)
+	q := &seedQueue{}
+	defer useSeedQueue(t, q)()
+	for _, kemName := range oqs.EnabledKEMs() {
+		ref, ok := goMLKEMs[kemName]
+		if !ok {
+			continue
+		}
+		log.Println("Differential - ", kemName)
+		for i := 0; i < differentialIterations; i++ {
+			seed := make([]byte, ref.lenSeed)
+			m := make([]byte, 32)
+			_, _ = gen.Read(seed)
+			_, _ = gen.Read(m)
+			if msg := testKEMDifferential(kemName, ref, seed, m, q); msg != "" {
+				t.Errorf("%s: %s (d || z = %x, m = %x; reproduce with "+
+					"-diffseed=%s)", kemName, msg, seed, m, master)
+			}
+		}
+	}
+}
+
+// testSigDifferential compares a single ML-DSA run of liboqs against
+// crypto/mldsa, and returns a description of the first divergence, or an empty
+// string if none was found.
+func testSigDifferential(sigName string, params mldsa.Parameters, xi []byte,
+	rnd []byte, msg []byte, context []byte, q *seedQueue,
+) string {
+	var signer oqs.Signature
+	defer signer.Clean()
+	// Ignore potential errors everywhere
+	_ = signer.Init(sigName, nil)
+
+	q.load(xi)
+	publicKey, _ := signer.GenerateKeyPair()
+	if !q.exhausted() {
+		return "keypair did not consume exactly xi"
+	}
+	refSecretKey, _ := mldsa.NewPrivateKey(params, xi)
+	if !bytes.Equal(publicKey, refSecretKey.PublicKey().Bytes()) {
+		return "public keys differ"
+	}
+	refPublicKey, err := mldsa.NewPublicKey(params, publicKey)
+	if err != nil {
+		return "crypto/mldsa rejected the public key: " + err.Error()
+	}
+	opts := &mldsa.Options{Context: string(context)}
+
+	// Hedged liboqs signature, verified by crypto/mldsa
+	q.load(rnd)
+	signature, _ := signer.SignWithCtxStr(msg, context)
+	if err := mldsa.Verify(refPublicKey, msg, signature, opts); err != nil {
+		return "crypto/mldsa rejected the liboqs signature: " + err.Error()
+	}
+
+	// crypto/mldsa signature, verified by liboqs
+	refSignature, _ := refSecretKey.Sign(nil, msg, opts)
+	isValid, _ := signer.VerifyWithCtxStr(msg, refSignature, context,
+		publicKey)
+	if !isValid {
+		return "liboqs rejected the crypto/mldsa signature"
+	}
+
+	// Both verifiers must reject a tampered signature
+	refSignature[0] ^= 0x01
+	isValid, _ = signer.VerifyWithCtxStr(msg, refSignature, context,
+		publicKey)
+	refErr := mldsa.Verify(refPublicKey, msg, refSignature, opts)
+	if isValid || refErr == nil {
+		return "a tampered signature was accepted"
+	}
+	return ""
+}
+
+// TestSignatureDifferential compares liboqs ML-DSA keys against crypto/mldsa,
+// and cross-verifies the signatures of both implementations.
+func TestSignatureDifferential(t *testing.T) {
+	gen, master := newDiffRNG(t)
+	q := &seedQueue{}
+	defer useSeedQueue(t, q)()
+	for _, sigName := range oqs.EnabledSigs() {
+		params, ok := goMLDSAs[sigName]
+		if !ok {
+			continue
+		}
+		log.Println("Differential - ", sigName)
+		for i := 0; i < differentialIterations; i++ {
+			xi := make([]byte, mldsa.PrivateKeySize)
+			rnd := make([]byte, 32)
+			msg := make([]byte, 1+gen.Uint64()%256)
+			context := make([]byte, gen.Uint64()%256)
+			_, _ = gen.Read(xi)
+			_, _ = gen.Read(rnd)
+			_, _ = gen.Read(msg)
+			_, _ = gen.Read(context)
+			divergence := testSigDifferential(sigName, params(), xi, rnd,
+				msg, context, q)
+			if divergence != "" {
+				t.Errorf("%s: %s (xi = %x, message = %x, context = %x; "+
+					"reproduce with -diffseed=%s)", sigName, divergence, xi,
+					msg, context, master)
+			}
+		}
+	}
+}
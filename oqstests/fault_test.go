@@ -0,0 +1,56 @@
+//go:build oqs_faultinject
+
+package oqstests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestVerifyAfterSignFault tests that a simulated fault is caught by
+// verify-after-sign, and that the faulty signature is zeroed and not
+// returned.
+func TestVerifyAfterSignFault(t *testing.T) {
+	var faulty []byte
+	oqs.SetSignatureFault(func(algName string, signature []byte) {
+		for i := range signature {
+			signature[i] ^= 0xff
+		}
+		faulty = signature
+	})
+	defer oqs.SetSignatureFault(nil)
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil, oqs.WithVerifyAfterSign())
+	_, _ = signer.GenerateKeyPair()
+	msg := []byte("This is our favourite message to sign")
+	signature, err := signer.Sign(msg)
+	if !errors.Is(err, oqs.ErrSignatureFault) || signature != nil {
+		t.Fatalf("expected oqs.ErrSignatureFault, got %v", err)
+	}
+	for _, b := range faulty {
+		if b != 0 {
+			t.Fatal("faulty signature not zeroed")
+		}
+	}
+	if _, errs := signer.SignBatch([][]byte{msg, msg}); !errors.Is(errs[1],
+		oqs.ErrSignatureFault) {
+		t.Errorf("expected oqs.ErrSignatureFault, got %v", errs[1])
+	}
+
+	// Without the countermeasure, the faulty signature is returned
+	var unprotected oqs.Signature
+	defer unprotected.Clean()
+	_ = unprotected.Init(firstSigName(t), nil)
+	publicKey, _ := unprotected.GenerateKeyPair()
+	signature, err = unprotected.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isValid, _ := unprotected.Verify(msg, signature, publicKey); isValid {
+		t.Error("the simulated fault had no effect")
+	}
+}
@@ -0,0 +1,268 @@
+package oqstests
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// The fuzz targets below feed arbitrary inputs to the parsing and verification
+// entry points of every enabled algorithm. The algorithm is selected by the
+// first fuzzing argument modulo the number of enabled algorithms, so that the
+// checked-in seed corpus in testdata/fuzz is valid for any liboqs build. Run
+// e.g. go test ./oqstests -run '^$' -fuzz FuzzVerify to start fuzzing.
+
+// fuzzMsg is the message signed when seeding the signature fuzz targets.
+var fuzzMsg = []byte("This is our favourite message to sign")
+
+// fuzzKEM caches a KEM with a generated key pair per algorithm, as well as a
+// second KEM in strict mode.
+type fuzzKEM struct {
+	client, strict oqs.KeyEncapsulation
+	publicKey      []byte
+}
+
+// fuzzSig caches a signature with a generated key pair per algorithm, as well
+// as a second signature in strict mode.
+type fuzzSig struct {
+	signer, strict oqs.Signature
+	publicKey      []byte
+}
+
+// fuzzKEMs and fuzzSigs are populated lazily by getFuzzKEM and getFuzzSig.
+var (
+	fuzzMu   sync.Mutex
+	fuzzKEMs = map[string]*fuzzKEM{}
+	fuzzSigs = map[string]*fuzzSig{}
+)
+
+// getFuzzKEM returns the cached KEM selected by algID.
+func getFuzzKEM(t testing.TB, algID uint16) *fuzzKEM {
+	if len(oqs.EnabledKEMs()) == 0 {
+		t.Skip("no KEM enabled")
+	}
+	kemName := oqs.EnabledKEMs()[int(algID)%len(oqs.EnabledKEMs())]
+	fuzzMu.Lock()
+	defer fuzzMu.Unlock()
+	if kem, ok := fuzzKEMs[kemName]; ok {
+		return kem
+	}
+	kem := &fuzzKEM{}
+	if err := kem.client.Init(kemName, nil); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := kem.client.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The strict KEM shares the secret key
+	if err := kem.strict.Init(kemName, kem.client.ExportSecretKey(),
+		oqs.WithStrictValidation()); err != nil {
+		t.Fatal(err)
+	}
+	kem.publicKey = publicKey
+	fuzzKEMs[kemName] = kem
+	return kem
+}
+
+// getFuzzSig returns the cached signature selected by algID.
+func getFuzzSig(t testing.TB, algID uint16) *fuzzSig {
+	if len(oqs.EnabledSigs()) == 0 {
+		t.Skip("no signature enabled")
+	}
+	sigName := oqs.EnabledSigs()[int(algID)%len(oqs.EnabledSigs())]
+	fuzzMu.Lock()
+	defer fuzzMu.Unlock()
+	if sig, ok := fuzzSigs[sigName]; ok {
+		return sig
+	}
+	sig := &fuzzSig{}
+	if err := sig.signer.Init(sigName, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sig.strict.Init(sigName, nil,
+		oqs.WithStrictValidation()); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := sig.signer.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig.publicKey = publicKey
+	fuzzSigs[sigName] = sig
+	return sig
+}
+
+// FuzzDecapSecret decapsulates arbitrary ciphertexts.
+func FuzzDecapSecret(f *testing.F) {
+	for i := range oqs.EnabledKEMs() {
+		kem := getFuzzKEM(f, uint16(i))
+		ciphertext, _, err := kem.client.EncapSecret(kem.publicKey)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(uint16(i), ciphertext)
+	}
+	f.Fuzz(func(t *testing.T, algID uint16, ciphertext []byte) {
+		kem := getFuzzKEM(t, algID)
+		details := kem.client.Details()
+		sharedSecret, err := kem.client.DecapSecret(ciphertext)
+		if len(ciphertext) != details.LengthCiphertext {
+			if err == nil {
+				t.Fatalf("%s: ciphertext of length %d accepted",
+					details.Name, len(ciphertext))
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", details.Name, err)
+		}
+		if len(sharedSecret) != details.LengthSharedSecret {
+			t.Fatalf("%s: shared secret of length %d", details.Name,
+				len(sharedSecret))
+		}
+		// Decapsulation is deterministic, also in strict mode
+		again, _ := kem.client.DecapSecret(ciphertext)
+		strict, _ := kem.strict.DecapSecret(ciphertext)
+		if !bytes.Equal(sharedSecret, again) ||
+			!bytes.Equal(sharedSecret, strict) {
+			t.Fatalf("%s: inconsistent shared secrets", details.Name)
+		}
+	})
+}
+
+// FuzzEncapSecret encapsulates against arbitrary public keys.
+func FuzzEncapSecret(f *testing.F) {
+	for i := range oqs.EnabledKEMs() {
+		f.Add(uint16(i), getFuzzKEM(f, uint16(i)).publicKey)
+	}
+	f.Fuzz(func(t *testing.T, algID uint16, publicKey []byte) {
+		kem := getFuzzKEM(t, algID)
+		details := kem.client.Details()
+		ciphertext, sharedSecret, err := kem.client.EncapSecret(publicKey)
+		if len(publicKey) != details.LengthPublicKey {
+			if err == nil {
+				t.Fatalf("%s: public key of length %d accepted",
+					details.Name, len(publicKey))
+			}
+			return
+		}
+		if err == nil && (len(ciphertext) != details.LengthCiphertext ||
+			len(sharedSecret) != details.LengthSharedSecret) {
+			t.Fatalf("%s: incorrect output lengths", details.Name)
+		}
+		// Strict mode must reject whatever fails validation
+		invalid := kem.strict.ValidatePublicKey(publicKey) != nil
+		_, _, strictErr := kem.strict.EncapSecret(publicKey)
+		if invalid && strictErr == nil {
+			t.Fatalf("%s: strict mode accepted an invalid public key",
+				details.Name)
+		}
+	})
+}
+
+// checkVerify checks the consistency of a verification result with the length
+// checks, the strict mode and a repeated verification.
+func checkVerify(t *testing.T, sig *fuzzSig, isValid bool, err error,
+	signature []byte, publicKey []byte, verify func(*oqs.Signature) (bool,
+		error),
+) {
+	details := sig.signer.Details()
+	if len(publicKey) != details.LengthPublicKey ||
+		len(signature) > details.MaxLengthSignature {
+		if err == nil {
+			t.Fatalf("%s: incorrect input lengths accepted", details.Name)
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+	again, _ := verify(&sig.signer)
+	if again != isValid {
+		t.Fatalf("%s: inconsistent verification results", details.Name)
+	}
+	strict, strictErr := verify(&sig.strict)
+	if strictErr == nil && strict != isValid {
+		t.Fatalf("%s: strict and non-strict verification disagree",
+			details.Name)
+	}
+	if strictErr != nil && isValid {
+		t.Fatalf("%s: a valid signature fails strict validation: %v",
+			details.Name, strictErr)
+	}
+}
+
+// FuzzVerify verifies arbitrary messages and signatures, against either the
+// cached public key or an arbitrary one.
+func FuzzVerify(f *testing.F) {
+	for i := range oqs.EnabledSigs() {
+		signature, err := getFuzzSig(f, uint16(i)).signer.Sign(fuzzMsg)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(uint16(i), fuzzMsg, signature, []byte(nil), true)
+	}
+	f.Fuzz(func(t *testing.T, algID uint16, message []byte,
+		signature []byte, publicKey []byte, useCachedKey bool,
+	) {
+		sig := getFuzzSig(t, algID)
+		if useCachedKey {
+			publicKey = sig.publicKey
+		}
+		verify := func(s *oqs.Signature) (bool, error) {
+			return s.Verify(message, signature, publicKey)
+		}
+		isValid, err := verify(&sig.signer)
+		checkVerify(t, sig, isValid, err, signature, publicKey, verify)
+		// An empty context string is the same as no context string
+		if err == nil && sig.signer.Details().SigWithCtxSupport {
+			isValidCtx, _ := sig.signer.VerifyWithCtxStr(message,
+				signature, nil, publicKey)
+			if isValidCtx != isValid {
+				t.Fatalf("%s: empty context string changes the result",
+					sig.signer.Details().Name)
+			}
+		}
+	})
+}
+
+// FuzzVerifyWithCtxStr verifies arbitrary messages, signatures and context
+// strings, against either the cached public key or an arbitrary one.
+func FuzzVerifyWithCtxStr(f *testing.F) {
+	context := []byte("fuzzing context")
+	for i := range oqs.EnabledSigs() {
+		sig := getFuzzSig(f, uint16(i))
+		if !sig.signer.Details().SigWithCtxSupport {
+			continue
+		}
+		signature, err := sig.signer.SignWithCtxStr(fuzzMsg, context)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(uint16(i), fuzzMsg, signature, context, []byte(nil), true)
+	}
+	f.Fuzz(func(t *testing.T, algID uint16, message []byte,
+		signature []byte, context []byte, publicKey []byte,
+		useCachedKey bool,
+	) {
+		sig := getFuzzSig(t, algID)
+		if useCachedKey {
+			publicKey = sig.publicKey
+		}
+		verify := func(s *oqs.Signature) (bool, error) {
+			return s.VerifyWithCtxStr(message, signature, context, publicKey)
+		}
+		isValid, err := verify(&sig.signer)
+		if len(context) > 0 && !sig.signer.Details().SigWithCtxSupport {
+			if err == nil {
+				t.Fatalf("%s: context string accepted",
+					sig.signer.Details().Name)
+			}
+			return
+		}
+		checkVerify(t, sig, isValid, err, signature, publicKey, verify)
+	})
+}
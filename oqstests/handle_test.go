@@ -0,0 +1,128 @@
+package oqstests
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// duplicate copies *src into *dst without go vet noticing, to test the copies
+// the noCopy guard warns about.
+func duplicate(dst, src any) {
+	reflect.ValueOf(dst).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
+// TestKEMCleanCopy tests cleaning a KEM and a copy of it, and using both
+// afterwards.
+func TestKEMCleanCopy(t *testing.T) {
+	var kem, dup oqs.KeyEncapsulation
+	if err := kem.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	publicKey, err := kem.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate(&dup, &kem)
+
+	kem.Clean()
+	dup.Clean()
+	if _, _, err := kem.EncapSecret(publicKey); !errors.Is(err, oqs.ErrCleaned) {
+		t.Errorf("EncapSecret after Clean: got %v, want ErrCleaned", err)
+	}
+	if _, _, err := dup.EncapSecret(publicKey); !errors.Is(err, oqs.ErrCleaned) {
+		t.Errorf("EncapSecret on a cleaned copy: got %v, want ErrCleaned", err)
+	}
+
+	if err := kem.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer kem.Clean()
+	if _, err := kem.GenerateKeyPair(); err != nil {
+		t.Errorf("can not reuse the KEM after Clean: %v", err)
+	}
+}
+
+// TestSigCleanCopy tests cleaning a copy of a signature object, and using the
+// original afterwards.
+func TestSigCleanCopy(t *testing.T) {
+	var signer, dup oqs.Signature
+	if err := signer.Init(benchSigName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Clean()
+	publicKey, err := signer.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicate(&dup, &signer)
+
+	dup.Clean()
+	if _, err := signer.Sign([]byte("message")); !errors.Is(err,
+		oqs.ErrCleaned) {
+		t.Errorf("Sign after cleaning a copy: got %v, want ErrCleaned", err)
+	}
+	if _, err := signer.Verify([]byte("message"), nil,
+		publicKey); !errors.Is(err, oqs.ErrCleaned) {
+		t.Errorf("Verify after cleaning a copy: got %v, want ErrCleaned", err)
+	}
+	_, errs := signer.SignBatch([][]byte{[]byte("message")})
+	if !errors.Is(errs[0], oqs.ErrCleaned) {
+		t.Errorf("SignBatch after cleaning a copy: got %v, want ErrCleaned",
+			errs[0])
+	}
+}
+
+// TestCleanDuringOperation tests that cleaning a copy does not free the
+// backend while an operation is using it.
+func TestCleanDuringOperation(t *testing.T) {
+	reader := newBlockingReader()
+	var kem, dup oqs.KeyEncapsulation
+	if err := kem.Init(benchKEMName(t), nil,
+		oqs.WithRandomReader(reader)); err != nil {
+		t.Fatal(err)
+	}
+	duplicate(&dup, &kem)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := kem.GenerateKeyPair()
+		done <- err
+	}()
+	<-reader.started
+	dup.Clean()
+	close(reader.release)
+	if err := <-done; err != nil {
+		t.Errorf("GenerateKeyPair failed during Clean: %v", err)
+	}
+	if _, err := kem.GenerateKeyPair(); !errors.Is(err, oqs.ErrCleaned) {
+		t.Errorf("GenerateKeyPair after Clean: got %v, want ErrCleaned", err)
+	}
+	kem.Clean()
+}
+
+// TestUninitialized tests the methods of objects that were not initialized.
+func TestUninitialized(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	if _, err := kem.GenerateKeyPair(); !errors.Is(err,
+		oqs.ErrNotInitialized) {
+		t.Errorf("KEM GenerateKeyPair: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := kem.DecapSecret(nil); !errors.Is(err,
+		oqs.ErrNotInitialized) {
+		t.Errorf("DecapSecret: got %v, want ErrNotInitialized", err)
+	}
+	kem.Clean()
+
+	var sig oqs.Signature
+	if _, err := sig.Verify(nil, nil, nil); !errors.Is(err,
+		oqs.ErrNotInitialized) {
+		t.Errorf("Verify: got %v, want ErrNotInitialized", err)
+	}
+	if _, err := sig.Sign(nil); !errors.Is(err, oqs.ErrNotInitialized) {
+		t.Errorf("Sign: got %v, want ErrNotInitialized", err)
+	}
+	sig.Clean()
+}
@@ -0,0 +1,149 @@
+package oqstests
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// useHealthTests enables the health tests with a custom RNG algorithm, and
+// returns a function restoring the default RNG algorithm.
+func useHealthTests(t *testing.T, minEntropy float64,
+	fun func([]byte, int),
+) func() {
+	if err := oqs.EnableRandomHealthTests(minEntropy); err != nil {
+		t.Fatal(err)
+	}
+	if err := oqs.RandomBytesCustomAlgorithm(fun); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		oqs.DisableRandomHealthTests()
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestHealthTestsMinEntropy tests the range of the assumed min-entropy.
+func TestHealthTestsMinEntropy(t *testing.T) {
+	defer oqs.DisableRandomHealthTests()
+	for _, minEntropy := range []float64{0, -1, 8.5} {
+		if err := oqs.EnableRandomHealthTests(minEntropy); err == nil {
+			t.Errorf("min-entropy %v accepted", minEntropy)
+		}
+	}
+}
+
+// TestHealthTestsRepetitionCount tests that a stuck RNG fails the repetition
+// count test, and that key generation is refused until the health tests are
+// reset.
+func TestHealthTestsRepetitionCount(t *testing.T) {
+	defer useHealthTests(t, 8, func(randomArray []byte, bytesToRead int) {
+		clear(randomArray[:bytesToRead])
+	})()
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(firstKEMName(t), nil)
+
+	_, err := kem.GenerateKeyPair()
+	var healthErr *oqs.HealthTestError
+	if !errors.As(err, &healthErr) || healthErr.Test != "repetition count" {
+		t.Fatalf("expected a repetition count failure, got %v", err)
+	}
+	if _, err := oqs.Reader.Read(make([]byte, 16)); err == nil {
+		t.Error("Reader succeeded after a health test failure")
+	}
+
+	// The failure is latched, even with a healthy RNG algorithm
+	if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kem.GenerateKeyPair(); !errors.As(err, &healthErr) {
+		t.Errorf("key generation not refused after a health test failure, "+
+			"got %v", err)
+	}
+	oqs.ResetRandomHealthTests()
+	if _, err := kem.GenerateKeyPair(); err != nil {
+		t.Errorf("key generation refused after reset: %v", err)
+	}
+}
+
+// TestHealthTestsAdaptiveProportion tests that a biased RNG, here the counter
+// of the examples/rand example, fails the adaptive proportion test.
+func TestHealthTestsAdaptiveProportion(t *testing.T) {
+	defer useHealthTests(t, 8, func(randomArray []byte, bytesToRead int) {
+		for i := 0; i < bytesToRead; i++ {
+			randomArray[i] = byte(i % 256)
+		}
+	})()
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil)
+
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		_, err = signer.GenerateKeyPair()
+	}
+	var healthErr *oqs.HealthTestError
+	if !errors.As(err, &healthErr) || healthErr.Test != "adaptive proportion" {
+		t.Fatalf("expected an adaptive proportion failure, got %v", err)
+	}
+}
+
+// TestHealthTestsHealthy tests that a healthy RNG passes the health tests.
+func TestHealthTestsHealthy(t *testing.T) {
+	defer useHealthTests(t, 4, func(randomArray []byte, bytesToRead int) {
+		_, _ = rand.Read(randomArray[:bytesToRead])
+	})()
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(firstKEMName(t), nil)
+	for i := 0; i < 64; i++ {
+		if _, err := kem.GenerateKeyPair(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := oqs.RandomHealthTestError(); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestHealthTestsReaders tests that the bytes of per-object readers and of
+// WithRandomSource are health tested as well.
+func TestHealthTestsReaders(t *testing.T) {
+	if err := oqs.EnableRandomHealthTests(8); err != nil {
+		t.Fatal(err)
+	}
+	defer oqs.DisableRandomHealthTests()
+	var healthErr *oqs.HealthTestError
+
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	if err := kem.Init(firstKEMName(t), nil,
+		oqs.WithRandomReader(constReader(0))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kem.GenerateKeyPair(); !errors.As(err, &healthErr) ||
+		healthErr.Test != "repetition count" {
+		t.Errorf("WithRandomReader: expected a repetition count failure, "+
+			"got %v", err)
+	}
+
+	oqs.ResetRandomHealthTests()
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil)
+	var err error
+	if scopeErr := oqs.WithRandomSource(constReader(0), func() {
+		_, err = signer.GenerateKeyPair()
+	}); scopeErr != nil {
+		t.Fatal(scopeErr)
+	}
+	if !errors.As(err, &healthErr) {
+		t.Errorf("WithRandomSource: expected a health test failure, got %v",
+			err)
+	}
+}
@@ -0,0 +1,167 @@
+package oqstests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"expvar"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+	"github.com/open-quantum-safe/liboqs-go/oqs/hooks"
+)
+
+// recordingHook records the events it observes.
+type recordingHook struct {
+	mu     sync.Mutex
+	events []oqs.Event
+}
+
+func (r *recordingHook) Observe(event oqs.Event) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+// TestHook tests that every instrumented operation reports an event with the
+// expected operation, sizes and outcome.
+func TestHook(t *testing.T) {
+	recorder := &recordingHook{}
+	oqs.SetHook(recorder)
+	defer oqs.SetHook(nil)
+
+	var client, server oqs.KeyEncapsulation
+	defer client.Clean()
+	defer server.Clean()
+	kemName := firstKEMName(t)
+	_ = client.Init(kemName, nil)
+	_ = server.Init(kemName, nil)
+	publicKey, _ := client.GenerateKeyPair()
+	ciphertext, _, _ := server.EncapSecret(publicKey)
+	_, _ = client.DecapSecret(ciphertext)
+	_, _ = client.DecapSecret(ciphertext[1:])
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	sigName := firstSigName(t)
+	_ = signer.Init(sigName, nil)
+	sigPublicKey, _ := signer.GenerateKeyPair()
+	msg := []byte("This is our favourite message to sign")
+	signature, _ := signer.Sign(msg)
+	_, _ = signer.Verify(msg, signature, sigPublicKey)
+	_, _ = signer.Verify(msg[1:], signature, sigPublicKey)
+
+	details := client.Details()
+	expected := []oqs.Event{
+		{Algorithm: kemName, Operation: oqs.OperationInit},
+		{Algorithm: kemName, Operation: oqs.OperationInit},
+		{Algorithm: kemName, Operation: oqs.OperationKeypair,
+			OutputSize: details.LengthPublicKey},
+		{Algorithm: kemName, Operation: oqs.OperationEncaps,
+			InputSize:  details.LengthPublicKey,
+			OutputSize: details.LengthCiphertext},
+		{Algorithm: kemName, Operation: oqs.OperationDecaps,
+			InputSize:  details.LengthCiphertext,
+			OutputSize: details.LengthSharedSecret},
+		{Algorithm: kemName, Operation: oqs.OperationDecaps,
+			InputSize:  details.LengthCiphertext - 1,
+			OutputSize: details.LengthSharedSecret},
+		{Algorithm: sigName, Operation: oqs.OperationInit},
+		{Algorithm: sigName, Operation: oqs.OperationKeypair,
+			OutputSize: signer.Details().LengthPublicKey},
+		{Algorithm: sigName, Operation: oqs.OperationSign,
+			InputSize: len(msg), OutputSize: len(signature)},
+		{Algorithm: sigName, Operation: oqs.OperationVerify,
+			InputSize: len(msg), OutputSize: len(signature), Valid: true},
+		{Algorithm: sigName, Operation: oqs.OperationVerify,
+			InputSize: len(msg) - 1, OutputSize: len(signature)},
+	}
+	outcomes := []string{"ok", "ok", "ok", "ok", "ok", "error", "ok", "ok",
+		"ok", "ok", "invalid"}
+	if len(recorder.events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected),
+			len(recorder.events))
+	}
+	for i, event := range recorder.events {
+		if event.Algorithm != expected[i].Algorithm ||
+			event.Operation != expected[i].Operation ||
+			event.InputSize != expected[i].InputSize ||
+			event.OutputSize != expected[i].OutputSize ||
+			event.Valid != expected[i].Valid ||
+			event.Outcome() != outcomes[i] {
+			t.Errorf("event %d: expected %+v (%s), got %+v (%s)", i,
+				expected[i], outcomes[i], event, event.Outcome())
+		}
+	}
+}
+
+// TestSlogHook tests the slog adapter, and that it never logs key material.
+func TestSlogHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf,
+		&slog.HandlerOptions{Level: slog.LevelDebug}))
+	oqs.SetHook(hooks.NewSlog(logger, slog.LevelDebug))
+	defer oqs.SetHook(nil)
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil)
+	publicKey, _ := signer.GenerateKeyPair()
+	_, _ = signer.Verify([]byte("msg"), []byte("not a signature"), publicKey)
+	_, _ = signer.Verify(nil, nil, publicKey[1:])
+
+	out := buf.String()
+	for _, s := range []string{"operation=keypair", "outcome=invalid",
+		"level=ERROR", "error=\"incorrect public key length\""} {
+		if !strings.Contains(out, s) {
+			t.Errorf("log does not contain %q:\n%s", s, out)
+		}
+	}
+	secretKey := signer.ExportSecretKey()
+	for _, key := range [][]byte{secretKey[:16], publicKey[:16]} {
+		if strings.Contains(strings.ToLower(out), hex.EncodeToString(key)) ||
+			bytes.Contains(buf.Bytes(), key) {
+			t.Error("key material logged")
+		}
+	}
+}
+
+// TestExpvarHook tests the expvar adapter counters.
+func TestExpvarHook(t *testing.T) {
+	hook := hooks.NewExpvar("oqstests")
+	if hooks.NewExpvar("oqstests").Map() != hook.Map() {
+		t.Error("published map not reused")
+	}
+	oqs.SetHook(hook)
+	defer oqs.SetHook(nil)
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	sigName := firstSigName(t)
+	_ = signer.Init(sigName, nil)
+	publicKey, _ := signer.GenerateKeyPair()
+	msg := []byte("msg")
+	signature, _ := signer.Sign(msg)
+	_, _ = signer.Verify(msg, signature, publicKey)
+	_, _ = signer.Verify(msg[1:], signature, publicKey)
+	_, _ = signer.Verify(msg, signature, publicKey[1:])
+
+	verify, ok := hook.Map().Get(sigName + ".verify").(*expvar.Map)
+	if !ok {
+		t.Fatal("no verify counters")
+	}
+	for counter, value := range map[string]string{"count": "3",
+		"errors": "1", "invalid": "1"} {
+		if got := verify.Get(counter).String(); got != value {
+			t.Errorf("%s: expected %s, got %s", counter, value, got)
+		}
+	}
+	sign := hook.Map().Get(sigName + ".sign").(*expvar.Map)
+	if got := sign.Get("output_bytes").String(); got !=
+		strconv.Itoa(len(signature)) {
+		t.Errorf("output_bytes: expected %d, got %s", len(signature), got)
+	}
+}
@@ -0,0 +1,162 @@
+package oqstests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+	"github.com/open-quantum-safe/liboqs-go/oqs/oqstest"
+)
+
+// kemRoundTrip and signRoundTrip exercise code written against the
+// interfaces, as downstream code would.
+func kemRoundTrip(t *testing.T, client, server oqs.KEM) {
+	t.Helper()
+	details := client.Details()
+	publicKey, err := client.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecretServer, err := server.EncapSecret(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedSecretClient, err := client.DecapSecret(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(publicKey) != details.LengthPublicKey ||
+		len(ciphertext) != details.LengthCiphertext ||
+		len(sharedSecretClient) != details.LengthSharedSecret {
+		t.Errorf("%s: lengths do not match the details", details.Name)
+	}
+	if !bytes.Equal(sharedSecretClient, sharedSecretServer) {
+		t.Errorf("%s: shared secrets do not coincide", details.Name)
+	}
+}
+
+func signRoundTrip(t *testing.T, signer oqs.Signer, verifier oqs.Verifier) {
+	t.Helper()
+	details := signer.Details()
+	publicKey, err := signer.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("This is our favourite message to sign")
+	signature, err := signer.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(publicKey) != details.LengthPublicKey ||
+		len(signature) > details.MaxLengthSignature {
+		t.Errorf("%s: lengths do not match the details", details.Name)
+	}
+	if isValid, err := verifier.Verify(msg, signature,
+		publicKey); err != nil || !isValid {
+		t.Errorf("%s: signature verification failed", details.Name)
+	}
+	if isValid, _ := verifier.Verify(append(msg, '!'), signature,
+		publicKey); isValid {
+		t.Errorf("%s: signature of another message verified", details.Name)
+	}
+}
+
+// TestInterfaces tests the real and the fake implementations through the
+// oqs.KEM, oqs.Signer and oqs.Verifier interfaces.
+func TestInterfaces(t *testing.T) {
+	var client, server oqs.KeyEncapsulation
+	defer client.Clean()
+	defer server.Clean()
+	_ = client.Init(firstKEMName(t), nil)
+	_ = server.Init(firstKEMName(t), nil)
+	kemRoundTrip(t, &client, &server)
+	fake := oqstest.NewKEM(oqstest.KEMDetails())
+	kemRoundTrip(t, fake, fake)
+
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(firstSigName(t), nil)
+	signRoundTrip(t, &signer, &signer)
+	fakeSig := oqstest.NewSignature(oqstest.SignatureDetails())
+	signRoundTrip(t, fakeSig, fakeSig)
+}
+
+// TestFakesDeterministic tests that fakes with the same details produce the
+// same outputs, and that Clean restarts the sequence.
+func TestFakesDeterministic(t *testing.T) {
+	details := oqstest.KEMDetails()
+	details.LengthSharedSecret = 64
+	a, b := oqstest.NewKEM(details), oqstest.NewKEM(details)
+	publicKeyA, _ := a.GenerateKeyPair()
+	publicKeyB, _ := b.GenerateKeyPair()
+	ciphertextA, sharedSecretA, _ := a.EncapSecret(publicKeyA)
+	ciphertextB, sharedSecretB, _ := b.EncapSecret(publicKeyB)
+	if !bytes.Equal(publicKeyA, publicKeyB) ||
+		!bytes.Equal(ciphertextA, ciphertextB) ||
+		!bytes.Equal(sharedSecretA, sharedSecretB) ||
+		len(sharedSecretA) != 64 {
+		t.Error("fake KEMs are not deterministic")
+	}
+	if publicKey, _ := a.GenerateKeyPair(); bytes.Equal(publicKey,
+		publicKeyA) {
+		t.Error("fake KEM generated the same key pair twice")
+	}
+	a.Clean()
+	if publicKey, _ := a.GenerateKeyPair(); !bytes.Equal(publicKey,
+		publicKeyA) {
+		t.Error("Clean did not restart the fake KEM")
+	}
+
+	s1 := oqstest.NewSignature(oqstest.SignatureDetails())
+	s2 := oqstest.NewSignature(oqstest.SignatureDetails())
+	_, _ = s1.GenerateKeyPair()
+	_, _ = s2.GenerateKeyPair()
+	signature1, _ := s1.SignWithCtxStr([]byte("msg"), []byte("ctx"))
+	signature2, _ := s2.SignWithCtxStr([]byte("msg"), []byte("ctx"))
+	if !bytes.Equal(signature1, signature2) {
+		t.Error("fake signatures are not deterministic")
+	}
+}
+
+// TestFakesFailures tests the configured errors and the length checks of the
+// fakes.
+func TestFakesFailures(t *testing.T) {
+	errInjected := errors.New("injected")
+	kem := oqstest.NewKEM(oqstest.KEMDetails())
+	if _, err := kem.DecapSecret(make([]byte,
+		kem.Details().LengthCiphertext)); err == nil {
+		t.Error("fake KEM decapsulated without a secret key")
+	}
+	publicKey, _ := kem.GenerateKeyPair()
+	if _, _, err := kem.EncapSecret(publicKey[1:]); err == nil {
+		t.Error("short public key accepted")
+	}
+	kem.EncapSecretErr = errInjected
+	if _, _, err := kem.EncapSecret(publicKey); err != errInjected {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+	kem.GenerateKeyPairErr = errInjected
+	if _, err := kem.GenerateKeyPair(); err != errInjected {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+
+	details := oqstest.SignatureDetails()
+	details.SigWithCtxSupport = false
+	sig := oqstest.NewSignature(details)
+	if _, err := sig.Sign(nil); err == nil {
+		t.Error("fake signature signed without a secret key")
+	}
+	publicKey, _ = sig.GenerateKeyPair()
+	if _, err := sig.SignWithCtxStr(nil, []byte("ctx")); err == nil {
+		t.Error("context string accepted without context support")
+	}
+	sig.VerifyErr = errInjected
+	if _, err := sig.Verify(nil, nil, publicKey); err != errInjected {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+	sig.SignErr = errInjected
+	if _, err := sig.Sign(nil); err != errInjected {
+		t.Errorf("expected the injected error, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+//go:build go1.24
+
+package oqstests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+	"github.com/open-quantum-safe/liboqs-go/oqs/kdf"
+)
+
+// kmacKey is the key of the NIST SP 800-185 KMAC samples.
+const kmacKey = "404142434445464748494a4b4c4d4e4f505152535455565758595a5b5c5d5e5f"
+
+// unhex decodes s, ignoring spaces.
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestKDFKnownAnswers tests the KDFs against the RFC 5869 and NIST SP 800-185
+// samples, and against outputs of OpenSSL 3.
+func TestKDFKnownAnswers(t *testing.T) {
+	ikm := unhex(t, "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	salt := unhex(t, "000102030405060708090a0b0c")
+	info := unhex(t, "f0f1f2f3f4f5f6f7f8f9")
+	sampleData := make([]byte, 200)
+	for i := range sampleData {
+		sampleData[i] = byte(i)
+	}
+	derive := func(out []byte, err error) []byte {
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		got  []byte
+		want string
+	}{
+		{
+			name: "HKDF-SHA256 RFC 5869 A.1",
+			got:  derive(kdf.HKDFSHA256(ikm, salt, info, 42)),
+			want: "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf" +
+				"34007208d5b887185865",
+		},
+		{
+			name: "HKDF-SHA384",
+			got:  derive(kdf.HKDFSHA384(ikm, salt, info, 48)),
+			want: "9b5097a86038b805309076a44b3a9f38063e25b516dcbf369f394cfab43685f7" +
+				"48b6457763e4f0204fc5d95d1da3e625",
+		},
+		{
+			name: "SHAKE256 empty",
+			got:  kdf.SHAKE256(nil, nil, 32),
+			want: "46b9dd2b0ba88d13233b3feb743eeb243fcd52ea62b81b82b50c27646ed5762f",
+		},
+		{
+			name: "SHAKE256",
+			got:  kdf.SHAKE256([]byte("shared secret"), []byte("info"), 32),
+			want: "75a465230b693b2f08227737e8f526ddd75d2706b8861fecd405c3facabc70c2",
+		},
+		{
+			name: "KMAC256 SP 800-185 sample 4",
+			got: kdf.KMAC256(unhex(t, kmacKey), []byte{0, 1, 2, 3},
+				[]byte("My Tagged Application"), 64),
+			want: "20c570c31346f703c9ac36c61c03cb64c3970d0cfc787e9b79599d273a68d2f7" +
+				"f69d4cc3de9d104a351689f27cf6f5951f0103f33f4f24871024d9c27773a8dd",
+		},
+		{
+			name: "KMAC256 SP 800-185 sample 6",
+			got: kdf.KMAC256(unhex(t, kmacKey), sampleData,
+				[]byte("My Tagged Application"), 64),
+			want: "b58618f71f92e1d56c1b8c55ddd7cd188b97b4ca4d99831eb2699a837da2e4d9" +
+				"70fbacfde50033aea585f1a2708510c32d07880801bd182898fe476876fc8965",
+		},
+		{
+			name: "KMAC256 without customization",
+			got:  kdf.KMAC256(unhex(t, kmacKey), []byte{0, 1, 2, 3}, nil, 64),
+			want: "2ebd1622de2de44174e3477206060d7f64489a639b7545649132317609fa214f" +
+				"4c8ac90630fb4c757fba074b15186fe452ae71b6a1e443bf54059e090c11ae20",
+		},
+		{
+			name: "AEADKey",
+			got: derive(kdf.AEADKey("ML-KEM-768",
+				[]byte("0123456789abcdef0123456789abcdef"), []byte("ct"),
+				[]byte("pk"), []byte("app"), 32)),
+			want: "69d18ebf6f9e4a6f91a5c97796841fa8d228b04ebf9249404845f76a342c177f",
+		},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(tt.got); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := kdf.HKDFSHA256(ikm, nil, nil, 255*32+1); err == nil {
+		t.Error("HKDFSHA256 accepted a length exceeding 255 blocks")
+	}
+	if _, err := kdf.AEADKey("ML-KEM-768", nil, nil, nil, nil, 32); err == nil {
+		t.Error("AEADKey accepted an empty shared secret")
+	}
+}
+
+// TestAEADKeyBinding tests that both sides of a KEM derive the same AEAD key,
+// and that the key depends on the algorithm, the ciphertext and the public
+// key.
+func TestAEADKeyBinding(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	if err := kem.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer kem.Clean()
+	name := kem.Details().Name
+	publicKey, err := kem.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext, sharedSecret, err := kem.EncapSecret(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := kem.DecapSecret(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sender, err := kdf.AEADKey(name, sharedSecret, ciphertext, publicKey, nil,
+		32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := kdf.AEADKey(name, recovered, ciphertext, publicKey, nil,
+		32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sender, recipient) {
+		t.Fatal("the sender and the recipient derived different keys")
+	}
+
+	flipped := func(b []byte) []byte {
+		b = bytes.Clone(b)
+		b[0] ^= 1
+		return b
+	}
+	variants := map[string]func() ([]byte, error){
+		"algorithm": func() ([]byte, error) {
+			return kdf.AEADKey(name+"x", sharedSecret, ciphertext, publicKey,
+				nil, 32)
+		},
+		"ciphertext": func() ([]byte, error) {
+			return kdf.AEADKey(name, sharedSecret, flipped(ciphertext),
+				publicKey, nil, 32)
+		},
+		"public key": func() ([]byte, error) {
+			return kdf.AEADKey(name, sharedSecret, ciphertext,
+				flipped(publicKey), nil, 32)
+		},
+		"info": func() ([]byte, error) {
+			return kdf.AEADKey(name, sharedSecret, ciphertext, publicKey,
+				[]byte("info"), 32)
+		},
+	}
+	for input, derive := range variants {
+		key, err := derive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(key, sender) {
+			t.Errorf("the AEAD key does not depend on the %s", input)
+		}
+	}
+}
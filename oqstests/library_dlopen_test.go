@@ -0,0 +1,111 @@
+//go:build oqs_dlopen && !oqs_purego
+
+package oqstests
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// libraryUnderTestEnv passes the path of liboqs to TestLibraryNotLoaded.
+const libraryUnderTestEnv = "LIBOQS_GO_TEST_LIBRARY"
+
+// TestLoadLibrary tests loading liboqs again.
+func TestLoadLibrary(t *testing.T) {
+	path := oqs.LibraryPath()
+	if path == "" {
+		t.Fatal("liboqs is not loaded")
+	}
+	if err := oqs.LoadLibrary(path); err != nil {
+		t.Errorf("loading liboqs again from the same path failed: %v", err)
+	}
+	var libErr *oqs.LibraryError
+	if err := oqs.LoadLibrary(path + ".other"); !errors.As(err, &libErr) {
+		t.Errorf("loading liboqs from another path: got %v, want a "+
+			"*LibraryError", err)
+	}
+}
+
+// TestLoadLibraryAtRuntime runs TestLibraryNotLoaded in a process where
+// liboqs can not be loaded at program start.
+func TestLoadLibraryAtRuntime(t *testing.T) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLibraryNotLoaded$",
+		"-test.v")
+	cmd.Env = append(os.Environ(), oqs.LibraryEnv+"=/nonexistent/liboqs.so",
+		libraryUnderTestEnv+"="+oqs.LibraryPath())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+// TestLibraryNotLoaded tests the package, including a custom RNG algorithm,
+// before and after loading liboqs with LoadLibrary. It only runs as a
+// subprocess of TestLoadLibraryAtRuntime.
+func TestLibraryNotLoaded(t *testing.T) {
+	path := os.Getenv(libraryUnderTestEnv)
+	if path == "" {
+		t.Skip("only runs as a subprocess of TestLoadLibraryAtRuntime")
+	}
+
+	if kems := oqs.EnabledKEMs(); len(kems) != 0 {
+		t.Errorf("KEMs enabled without liboqs: %v", kems)
+	}
+	var libErr *oqs.LibraryError
+	var kem oqs.KeyEncapsulation
+	if err := kem.Init("ML-KEM-768", nil); !errors.As(err, &libErr) {
+		t.Errorf("Init without liboqs: got %v, want a *LibraryError", err)
+	}
+	// A custom RNG algorithm serves the randomness until liboqs is loaded, and
+	// is carried over to it
+	custom := func(randomArray []byte, bytesToRead int) {
+		_, _ = constReader(0x01).Read(randomArray[:bytesToRead])
+	}
+	if err := oqs.RandomBytesCustomAlgorithm(custom); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Error(err)
+		}
+	}()
+	if random := oqs.RandomBytes(4); !bytes.Equal(random,
+		[]byte{1, 1, 1, 1}) {
+		t.Errorf("custom RNG algorithm not in use without liboqs, got %x",
+			random)
+	}
+	if runtime.GOOS == "linux" {
+		err := oqs.LoadLibrary("libc.so.6")
+		if !errors.As(err, &libErr) || libErr.Symbol == "" {
+			t.Errorf("loading a library without the liboqs symbols: got "+
+				"%v, want a *LibraryError naming a symbol", err)
+		}
+	}
+
+	if err := oqs.LoadLibrary(path); err != nil {
+		t.Fatal(err)
+	}
+	if oqs.LibraryPath() != path {
+		t.Errorf("LibraryPath: got %q, want %q", oqs.LibraryPath(), path)
+	}
+	if len(oqs.EnabledKEMs()) == 0 || len(oqs.EnabledSigs()) == 0 {
+		t.Error("no algorithm enabled after LoadLibrary")
+	}
+	if random := oqs.RandomBytes(4); !bytes.Equal(random,
+		[]byte{1, 1, 1, 1}) {
+		t.Errorf("custom RNG algorithm dropped by LoadLibrary, got %x", random)
+	}
+	if err := kem.Init(firstKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer kem.Clean()
+	if _, err := kem.GenerateKeyPair(); err != nil {
+		t.Error(err)
+	}
+}
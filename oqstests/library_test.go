@@ -0,0 +1,22 @@
+//go:build !oqs_dlopen || oqs_purego
+
+package oqstests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestLoadLibrary tests that liboqs can not be loaded at runtime without the
+// oqs_dlopen build tag.
+func TestLoadLibrary(t *testing.T) {
+	var libErr *oqs.LibraryError
+	if err := oqs.LoadLibrary("liboqs.so"); !errors.As(err, &libErr) {
+		t.Errorf("LoadLibrary: got %v, want a *LibraryError", err)
+	}
+	if path := oqs.LibraryPath(); path != "" {
+		t.Errorf("LibraryPath: got %q, want an empty path", path)
+	}
+}
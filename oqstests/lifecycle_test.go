@@ -0,0 +1,180 @@
+package oqstests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestShutdown tests that the operations fail with ErrShutdown after Shutdown,
+// and work again after Initialize.
+func TestShutdown(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	if err := kem.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer kem.Clean()
+	publicKey, err := kem.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var signer oqs.Signature
+	if err := signer.Init(benchSigName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Clean()
+	if _, err := signer.GenerateKeyPair(); err != nil {
+		t.Fatal(err)
+	}
+
+	oqs.Shutdown()
+	shutdown := true
+	defer func() {
+		if shutdown {
+			_ = oqs.Initialize()
+		}
+	}()
+	if _, _, err := kem.EncapSecret(publicKey); !errors.Is(err,
+		oqs.ErrShutdown) {
+		t.Errorf("EncapSecret after Shutdown: got %v, want ErrShutdown", err)
+	}
+	if _, err := signer.Sign([]byte("message")); !errors.Is(err,
+		oqs.ErrShutdown) {
+		t.Errorf("Sign after Shutdown: got %v, want ErrShutdown", err)
+	}
+	var other oqs.KeyEncapsulation
+	if err := other.Init(benchKEMName(t), nil); !errors.Is(err,
+		oqs.ErrShutdown) {
+		other.Clean()
+		t.Errorf("Init after Shutdown: got %v, want ErrShutdown", err)
+	}
+	if _, err := oqs.Reader.Read(make([]byte, 16)); !errors.Is(err,
+		oqs.ErrShutdown) {
+		t.Errorf("Reader.Read after Shutdown: got %v, want ErrShutdown", err)
+	}
+	if err := oqs.ReadRandomBytes(make([]byte, 16)); !errors.Is(err,
+		oqs.ErrShutdown) {
+		t.Errorf("ReadRandomBytes after Shutdown: got %v, want ErrShutdown",
+			err)
+	}
+	if err := oqs.RandomBytesSwitchAlgorithm("system"); !errors.Is(err,
+		oqs.ErrShutdown) {
+		t.Errorf("RandomBytesSwitchAlgorithm after Shutdown: got %v, want "+
+			"ErrShutdown", err)
+	}
+	if err := oqs.RandomBytesCustomAlgorithm(func([]byte, int) {
+	}); !errors.Is(err, oqs.ErrShutdown) {
+		t.Errorf("RandomBytesCustomAlgorithm after Shutdown: got %v, want "+
+			"ErrShutdown", err)
+	}
+	// The random bytes functions that can not return an error panic
+	for name, fn := range map[string]func(){
+		"RandomBytes": func() { oqs.RandomBytes(16) },
+		"RandomBytesInPlace": func() {
+			oqs.RandomBytesInPlace(make([]byte, 16), 16)
+		},
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != oqs.ErrShutdown {
+					t.Errorf("%s after Shutdown: got panic %v, want "+
+						"ErrShutdown", name, r)
+				}
+			}()
+			fn()
+		}()
+	}
+	oqs.Shutdown() // shutting down twice does nothing
+
+	if err := oqs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	shutdown = false
+	ciphertext, sharedSecret, err := kem.EncapSecret(publicKey)
+	if err != nil {
+		t.Fatalf("EncapSecret after Initialize: %v", err)
+	}
+	recovered, err := kem.DecapSecret(ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sharedSecret, recovered) {
+		t.Error("the shared secrets differ after Initialize")
+	}
+	if _, err := signer.Sign([]byte("message")); err != nil {
+		t.Errorf("Sign after Initialize: %v", err)
+	}
+}
+
+// TestShutdownWaits tests that Shutdown waits for the operations in progress,
+// and returns once they end.
+func TestShutdownWaits(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	custom := func(randomArray []byte, bytesToRead int) {
+		close(started)
+		<-release
+		clear(randomArray[:bytesToRead])
+	}
+	if err := oqs.RandomBytesCustomAlgorithm(custom); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Error(err)
+		}
+	}()
+	read := make(chan error)
+	go func() {
+		read <- oqs.ReadRandomBytes(make([]byte, 16))
+	}()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		oqs.Shutdown()
+	}()
+	select {
+	case <-stopped:
+		t.Error("Shutdown returned while an operation is in progress")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if err := <-read; err != nil {
+		t.Errorf("operation in progress failed: %v", err)
+	}
+	<-stopped
+	if err := oqs.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestRunOnThread tests that RunOnThread runs its function, and releases the
+// per-thread resources afterwards.
+func TestRunOnThread(t *testing.T) {
+	sigName := benchSigName(t)
+	var signature []byte
+	var err error
+	oqs.RunOnThread(func() {
+		var signer oqs.Signature
+		if err = signer.Init(sigName, nil); err != nil {
+			return
+		}
+		defer signer.Clean()
+		if _, err = signer.GenerateKeyPair(); err != nil {
+			return
+		}
+		signature, err = signer.Sign([]byte("message"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signature) == 0 {
+		t.Error("RunOnThread did not run the function")
+	}
+	oqs.ThreadStop() // does nothing if the thread has no resources
+}
@@ -0,0 +1,157 @@
+package oqstests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestAlgorithmFamily tests the family and standardization status of a few
+// liboqs algorithm names.
+func TestAlgorithmFamily(t *testing.T) {
+	tests := []struct{ name, family, status string }{
+		{"ML-KEM-768", "ML-KEM", oqs.StatusStandardized},
+		{"ML-DSA-44", "ML-DSA", oqs.StatusStandardized},
+		{"SLH_DSA_PURE_SHA2_128S", "SLH-DSA", oqs.StatusStandardized},
+		{"Kyber512", "Kyber", oqs.StatusDeprecated},
+		{"SPHINCS+-SHA2-128f-simple", "SPHINCS+", oqs.StatusDeprecated},
+		{"eFrodoKEM-640-AES", "FrodoKEM", oqs.StatusNonStandard},
+		{"Falcon-padded-512", "Falcon", oqs.StatusNonStandard},
+		{"Unknown-1", "Unknown-1", oqs.StatusNonStandard},
+	}
+	for _, tt := range tests {
+		if family := oqs.AlgorithmFamily(tt.name); family != tt.family {
+			t.Errorf("%s: expected family %s, got %s", tt.name, tt.family,
+				family)
+		}
+		if status := oqs.StandardizationStatus(tt.name); status != tt.status {
+			t.Errorf("%s: expected status %s, got %s", tt.name, tt.status,
+				status)
+		}
+	}
+}
+
+// TestPolicy tests that Init enforces the policy, with typed errors and audit
+// decisions.
+func TestPolicy(t *testing.T) {
+	defer oqs.ResetPolicy()
+	var decisions []oqs.PolicyDecision
+	audit := func(decision oqs.PolicyDecision) {
+		decisions = append(decisions, decision)
+	}
+
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	kemName := firstKEMName(t)
+	_ = kem.Init(kemName, nil)
+	details := kem.Details()
+	kem.Clean()
+
+	tests := []struct {
+		policy oqs.Policy
+		rule   string
+	}{
+		{oqs.Policy{}, ""},
+		{oqs.Policy{MinNISTLevel: details.ClaimedNISTLevel}, ""},
+		{oqs.Policy{MinNISTLevel: details.ClaimedNISTLevel + 1},
+			oqs.PolicyRuleMinNISTLevel},
+		{oqs.Policy{AllowedFamilies: []string{"No-Such-Family"}},
+			oqs.PolicyRuleAllowedFamilies},
+		{oqs.Policy{AllowedFamilies: []string{oqs.AlgorithmFamily(kemName)}},
+			""},
+		{oqs.Policy{AllowedStatuses: []string{
+			oqs.StandardizationStatus(kemName)}}, ""},
+		{oqs.Policy{MaxPublicKeyLength: details.LengthPublicKey - 1},
+			oqs.PolicyRuleMaxPublicKeyLength},
+		{oqs.Policy{MaxCiphertextLength: details.LengthCiphertext}, ""},
+		{oqs.Policy{MaxCiphertextLength: details.LengthCiphertext - 1},
+			oqs.PolicyRuleMaxCiphertextLength},
+	}
+	for i, tt := range tests {
+		p := tt.policy
+		p.Audit = audit
+		oqs.SetPolicy(&p)
+		decisions = nil
+		err := kem.Init(kemName, nil)
+		kem.Clean()
+		var policyErr *oqs.PolicyError
+		if tt.rule == "" && err != nil {
+			t.Errorf("test %d: %v", i, err)
+		} else if tt.rule != "" && (!errors.As(err, &policyErr) ||
+			policyErr.Rule != tt.rule || policyErr.Algorithm != kemName) {
+			t.Errorf("test %d: expected a %s violation, got %v", i, tt.rule,
+				err)
+		}
+		if len(decisions) != 1 || decisions[0].Type != "KEM" ||
+			(decisions[0].Err == nil) != (tt.rule == "") {
+			t.Errorf("test %d: unexpected audit decisions %+v", i, decisions)
+		}
+	}
+
+	var sig oqs.Signature
+	defer sig.Clean()
+	sigName := firstSigName(t)
+	_ = sig.Init(sigName, nil)
+	maxLengthSignature := sig.Details().MaxLengthSignature
+	sig.Clean()
+	oqs.SetPolicy(&oqs.Policy{MaxSignatureLength: maxLengthSignature - 1})
+	var policyErr *oqs.PolicyError
+	if err := sig.Init(sigName, nil); !errors.As(err, &policyErr) ||
+		policyErr.Rule != oqs.PolicyRuleMaxSignatureLength {
+		t.Errorf("expected a signature length violation, got %v", err)
+	}
+}
+
+// TestPolicyFromEnv tests the policy held by the environment, both inline and
+// in a file, and that SetPolicy overrides it.
+func TestPolicyFromEnv(t *testing.T) {
+	defer oqs.ResetPolicy()
+	oqs.ResetPolicy()
+	kemName := firstKEMName(t)
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+
+	t.Setenv(oqs.PolicyEnv, `{"allowed_families": ["No-Such-Family"]}`)
+	var policyErr *oqs.PolicyError
+	if err := kem.Init(kemName, nil); !errors.As(err, &policyErr) {
+		t.Errorf("expected a policy violation, got %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	_ = os.WriteFile(path, []byte(`{"min_nist_level": 1}`), 0o600)
+	t.Setenv(oqs.PolicyEnv, path)
+	if err := kem.Init(kemName, nil); err != nil {
+		t.Error(err)
+	}
+	kem.Clean()
+
+	t.Setenv(oqs.PolicyEnv, `{"min_nist_level": "high"}`)
+	if err := kem.Init(kemName, nil); err == nil ||
+		errors.As(err, &policyErr) {
+		t.Errorf("expected a parse error, got %v", err)
+	}
+	t.Setenv(oqs.PolicyEnv, `{"min_nist_level": 3} {"min_nist_level": 1}`)
+	if err := kem.Init(kemName, nil); err == nil ||
+		errors.As(err, &policyErr) {
+		t.Errorf("expected a parse error for trailing data, got %v", err)
+	}
+	oqs.SetPolicy(nil)
+	if err := kem.Init(kemName, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, data := range []string{`{"min_level": 1}`,
+		`{"allowed_statuses": ["final"]}`, `{"min_nist_level": 1} garbage`,
+		`{"min_nist_level": 1} {}`, `{"min_nist_level": 1}]`} {
+		if _, err := oqs.ParsePolicy([]byte(data)); err == nil {
+			t.Errorf("invalid policy %s accepted", data)
+		}
+	}
+	if _, err := oqs.ParsePolicy([]byte(`{"min_nist_level": 1}` +
+		"\n")); err != nil {
+		t.Errorf("trailing whitespace rejected: %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package oqstests
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// constReader is an endless deterministic random source.
+type constReader byte
+
+func (r constReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = byte(r)
+	}
+	return len(b), nil
+}
+
+// TestRandomBytesZeroLength tests that requesting no randomness is a no-op.
+func TestRandomBytesZeroLength(t *testing.T) {
+	if random := oqs.RandomBytes(0); len(random) != 0 {
+		t.Errorf("RandomBytes(0) returned %d bytes", len(random))
+	}
+	oqs.RandomBytesInPlace(nil, 0)
+	oqs.RandomBytesInPlace([]byte{}, 16)
+	if n, err := oqs.Reader.Read(nil); n != 0 || err != nil {
+		t.Errorf("Reader.Read(nil) = %d, %v", n, err)
+	}
+}
+
+// TestReader tests that oqs.Reader fills the whole buffer.
+func TestReader(t *testing.T) {
+	random := make([]byte, 64)
+	if _, err := io.ReadFull(oqs.Reader, random); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(random, make([]byte, len(random))) {
+		t.Error("oqs.Reader returned only zeroes")
+	}
+}
+
+// TestWithRandomSource tests that WithRandomSource derandomizes key
+// generation, on the calling goroutine only with the liboqs backend, and leaves
+// the RNG algorithm unchanged.
+func TestWithRandomSource(t *testing.T) {
+	if algName := oqs.CurrentRandomAlgorithm(); algName != "system" {
+		t.Fatalf("unexpected default RNG algorithm %q", algName)
+	}
+	kemName := firstKEMName(t)
+	keyPair := func() []byte {
+		var kem oqs.KeyEncapsulation
+		defer kem.Clean()
+		// Ignore potential errors everywhere
+		_ = kem.Init(kemName, nil)
+		publicKey, _ := kem.GenerateKeyPair()
+		return publicKey
+	}
+	// Only the liboqs backend dispatches on the calling thread, the other
+	// backends serialize the scopes and apply them to the whole process
+	perThread := oqs.Backend() == "liboqs"
+
+	var first, second, other, nested []byte
+	err := oqs.WithRandomSource(constReader(0x2a), func() {
+		if algName := oqs.CurrentRandomAlgorithm(); algName != "system" {
+			t.Errorf("unexpected RNG algorithm %q in scope", algName)
+		}
+		first = keyPair()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			other = keyPair()
+		}()
+		<-done
+		if !perThread {
+			return
+		}
+		// The innermost reader wins
+		if err := oqs.WithRandomSource(constReader(0x2b), func() {
+			nested = keyPair()
+		}); err != nil {
+			t.Error(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := oqs.WithRandomSource(constReader(0x2a), func() {
+		second = keyPair()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("%s: key generation is not deterministic", kemName)
+	}
+	if perThread {
+		if bytes.Equal(other, first) {
+			t.Errorf("%s: randomness leaked to another goroutine", kemName)
+		}
+		if bytes.Equal(nested, first) {
+			t.Errorf("%s: nested random source ignored", kemName)
+		}
+	} else if !bytes.Equal(other, first) {
+		t.Errorf("%s: the random source does not apply to another "+
+			"goroutine", kemName)
+	}
+	if bytes.Equal(keyPair(), first) {
+		t.Errorf("%s: randomness not restored", kemName)
+	}
+}
+
+// TestWithRandomSourceSerialized tests that concurrent WithRandomSource calls
+// each read from their own random source.
+func TestWithRandomSourceSerialized(t *testing.T) {
+	kemName := firstKEMName(t)
+	const numScopes = 8
+	publicKeys := make([][]byte, numScopes)
+	var wg sync.WaitGroup
+	for i := 0; i < numScopes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := oqs.WithRandomSource(constReader(i%2), func() {
+				var kem oqs.KeyEncapsulation
+				defer kem.Clean()
+				if err := kem.Init(kemName, nil); err != nil {
+					t.Error(err)
+					return
+				}
+				publicKeys[i], _ = kem.GenerateKeyPair()
+			}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i := 2; i < numScopes; i++ {
+		if !bytes.Equal(publicKeys[i], publicKeys[i%2]) {
+			t.Errorf("scope %d: key generation used another random source",
+				i)
+		}
+	}
+}
+
+// TestWithRandomSourceFailure tests that WithRandomSource reports the errors
+// of its random source, rather than panicking, and that it leaves a custom RNG
+// algorithm in place.
+func TestWithRandomSourceFailure(t *testing.T) {
+	kemName := firstKEMName(t)
+	custom := func(randomArray []byte, bytesToRead int) {
+		_, _ = constReader(0x01).Read(randomArray[:bytesToRead])
+	}
+	if err := oqs.RandomBytesCustomAlgorithm(custom); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err := oqs.WithRandomSource(bytes.NewReader(nil), func() {
+		if _, err := oqs.Reader.Read(make([]byte, 16)); err == nil {
+			t.Error("Reader succeeded with a failing random source")
+		}
+		var kem oqs.KeyEncapsulation
+		defer kem.Clean()
+		if err := kem.Init(kemName, nil); err != nil {
+			t.Fatal(err)
+		}
+		if publicKey, err := kem.GenerateKeyPair(); err == nil {
+			t.Errorf("key generation succeeded with a failing random "+
+				"source: %x", publicKey)
+		}
+	})
+	if err == nil {
+		t.Error("WithRandomSource did not report the failing random source")
+	}
+	if algName := oqs.CurrentRandomAlgorithm(); algName !=
+		oqs.CustomRandomAlgorithm {
+		t.Errorf("RNG algorithm changed to %q", algName)
+	}
+	if random := oqs.RandomBytes(4); !bytes.Equal(random,
+		[]byte{1, 1, 1, 1}) {
+		t.Errorf("custom RNG algorithm not in use, got %x", random)
+	}
+}
+
+// TestWithRandomReader tests that per-object readers derandomize key
+// generation independently of each other and of the process-wide RNG
+// algorithm, also when used concurrently.
+func TestWithRandomReader(t *testing.T) {
+	kemName := firstKEMName(t)
+	sigName := firstSigName(t)
+	keyPairs := func(r io.Reader) ([]byte, []byte) {
+		var kem oqs.KeyEncapsulation
+		var signer oqs.Signature
+		defer kem.Clean()
+		defer signer.Clean()
+		if err := kem.Init(kemName, nil, oqs.WithRandomReader(r)); err != nil {
+			t.Error(err)
+			return nil, nil
+		}
+		if err := signer.Init(sigName, nil,
+			oqs.WithRandomReader(r)); err != nil {
+			t.Error(err)
+			return nil, nil
+		}
+		if r != nil {
+			if err := oqs.RandomBytesSwitchAlgorithm("OpenSSL"); err == nil {
+				t.Error("switching to OpenSSL should fail while per-object " +
+					"readers are in use")
+				_ = oqs.RandomBytesSwitchAlgorithm("system")
+			}
+		}
+		kemPublicKey, _ := kem.GenerateKeyPair()
+		sigPublicKey, _ := signer.GenerateKeyPair()
+		// Encapsulation and signing consume the reader as well
+		if _, _, err := kem.EncapSecret(kemPublicKey); err != nil {
+			t.Error(err)
+		}
+		if _, err := signer.Sign([]byte("message")); err != nil {
+			t.Error(err)
+		}
+		return kemPublicKey, sigPublicKey
+	}
+
+	const numReaders = 4
+	var wg sync.WaitGroup
+	kemPublicKeys := make([][2][]byte, numReaders)
+	sigPublicKeys := make([][2][]byte, numReaders)
+	for i := 0; i < numReaders; i++ {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				kemPublicKeys[i][j], sigPublicKeys[i][j] = keyPairs(
+					constReader(i))
+			}(i, j)
+		}
+	}
+	// Objects without a reader keep using the process-wide RNG algorithm
+	random, _ := keyPairs(nil)
+	wg.Wait()
+
+	for i := 0; i < numReaders; i++ {
+		if !bytes.Equal(kemPublicKeys[i][0], kemPublicKeys[i][1]) ||
+			!bytes.Equal(sigPublicKeys[i][0], sigPublicKeys[i][1]) {
+			t.Errorf("reader %d: key generation is not deterministic", i)
+		}
+		if i > 0 && bytes.Equal(kemPublicKeys[i][0], kemPublicKeys[0][0]) {
+			t.Errorf("reader %d: key generation ignores the reader", i)
+		}
+		if bytes.Equal(random, kemPublicKeys[i][0]) {
+			t.Errorf("reader %d: randomness leaked to another object", i)
+		}
+	}
+	if algName := oqs.CurrentRandomAlgorithm(); algName != "system" {
+		t.Errorf("RNG algorithm changed to %q", algName)
+	}
+	// The RNG algorithm can be switched again once the readers are released
+	if slices.Contains(oqs.Compatibility().BuildFlags, "OQS_USE_OPENSSL") {
+		if err := oqs.RandomBytesSwitchAlgorithm("OpenSSL"); err != nil {
+			t.Errorf("can not switch to OpenSSL after the readers are "+
+				"released: %v", err)
+		}
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestWithRandomReaderFailure tests that a failing per-object reader makes
+// key generation return an error.
+func TestWithRandomReaderFailure(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	err := kem.Init(firstKEMName(t), nil,
+		oqs.WithRandomReader(bytes.NewReader(nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publicKey, err := kem.GenerateKeyPair(); err == nil {
+		t.Errorf("key generation succeeded with a failing reader: %x",
+			publicKey)
+	}
+}
+
+// slowFailingReader fails after sleeping, so that the concurrent reads
+// overlap even on a single CPU.
+type slowFailingReader struct{}
+
+func (slowFailingReader) Read([]byte) (int, error) {
+	time.Sleep(time.Millisecond)
+	return 0, errors.New("failing reader")
+}
+
+// TestWithRandomReaderConcurrentFailure tests that the copies of an object
+// sharing a failing reader all report its error, also when used concurrently.
+func TestWithRandomReaderConcurrentFailure(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	err := kem.Init(firstKEMName(t), nil,
+		oqs.WithRandomReader(slowFailingReader{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const numCopies = 8
+	var wg sync.WaitGroup
+	for i := 0; i < numCopies; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dup oqs.KeyEncapsulation
+			duplicate(&dup, &kem)
+			for j := 0; j < 4; j++ {
+				if publicKey, err := dup.GenerateKeyPair(); err == nil {
+					t.Errorf("key generation succeeded with a failing "+
+						"reader: %x", publicKey)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,183 @@
+//go:build go1.24
+
+package oqstests
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestSealOpen tests sealing to and opening with every enabled KEM, with a
+// generated and with an imported key pair.
+func TestSealOpen(t *testing.T) {
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		disabledKEMPatterns = []string{}
+	}
+	plaintext := []byte("attack at dawn")
+	aad := []byte("header")
+	for _, kemName := range oqs.EnabledKEMs() {
+		if stringMatchSlice(kemName, disabledKEMPatterns) {
+			continue
+		}
+		t.Run(kemName, func(t *testing.T) {
+			var recipient oqs.KeyEncapsulation
+			defer recipient.Clean()
+			if err := recipient.Init(kemName, nil); err != nil {
+				t.Fatal(err)
+			}
+			publicKey, err := recipient.GenerateKeyPair()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sealed, err := oqs.Seal(kemName, publicKey, plaintext, aad,
+				oqs.WithAEAD(oqs.AES256GCM))
+			if err != nil {
+				t.Fatal(err)
+			}
+			opened, err := recipient.Open(sealed, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Errorf("got %q, want %q", opened, plaintext)
+			}
+
+			// A recipient passing its secret key to Init imports its public
+			// key
+			var imported oqs.KeyEncapsulation
+			defer imported.Clean()
+			if err := imported.Init(kemName,
+				recipient.ExportSecretKey()); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := imported.Open(sealed, aad); err == nil {
+				t.Error("Open succeeded without the public key")
+			}
+			if err := imported.ImportPublicKey(publicKey); err != nil {
+				t.Fatal(err)
+			}
+			opened, err = imported.Open(sealed, aad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(opened, plaintext) {
+				t.Errorf("imported key pair: got %q, want %q", opened,
+					plaintext)
+			}
+		})
+	}
+}
+
+// TestOpenTampered tests that truncated, tampered and foreign envelopes are
+// rejected.
+func TestOpenTampered(t *testing.T) {
+	var recipient oqs.KeyEncapsulation
+	if err := recipient.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer recipient.Clean()
+	publicKey, err := recipient.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := []byte("header")
+	sealed, err := oqs.Seal(benchKEMName(t), publicKey, []byte("message"), aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lengthCiphertext := recipient.Details().LengthCiphertext
+
+	tampered := func(offset int) []byte {
+		b := bytes.Clone(sealed)
+		b[offset] ^= 1
+		return b
+	}
+	tests := []struct {
+		name   string
+		sealed []byte
+		aad    []byte
+		want   error
+	}{
+		{"empty", nil, aad, oqs.ErrEnvelopeTruncated},
+		{"header only", sealed[:2], aad, oqs.ErrEnvelopeTruncated},
+		{"truncated tag", sealed[:len(sealed)-1], aad,
+			oqs.ErrEnvelopeAuthentication},
+		{"no tag", sealed[:2+lengthCiphertext+12], aad,
+			oqs.ErrEnvelopeTruncated},
+		{"version", tampered(0), aad, oqs.ErrEnvelopeUnsupported},
+		{"unknown AEAD", tampered(1), aad, oqs.ErrEnvelopeUnsupported},
+		{"KEM ciphertext", tampered(2), aad, oqs.ErrEnvelopeAuthentication},
+		{"nonce", tampered(2 + lengthCiphertext), aad,
+			oqs.ErrEnvelopeAuthentication},
+		{"AEAD ciphertext", tampered(len(sealed) - 1), aad,
+			oqs.ErrEnvelopeAuthentication},
+		{"additional data", sealed, []byte("other"),
+			oqs.ErrEnvelopeAuthentication},
+	}
+	for _, tt := range tests {
+		_, err := recipient.Open(tt.sealed, tt.aad)
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// The AEAD identifier 2 is reserved for ChaCha20-Poly1305
+	switched := bytes.Clone(sealed)
+	switched[1] = 2
+	if _, err := recipient.Open(switched, aad); !errors.Is(err,
+		oqs.ErrEnvelopeUnsupported) {
+		t.Errorf("reserved AEAD: got %v, want ErrEnvelopeUnsupported", err)
+	}
+
+	var other oqs.KeyEncapsulation
+	if err := other.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer other.Clean()
+	otherPublicKey, err := other.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Open(sealed, aad); !errors.Is(err,
+		oqs.ErrEnvelopeAuthentication) {
+		t.Errorf("other recipient: got %v, want ErrEnvelopeAuthentication",
+			err)
+	}
+	// The public key of the recipient is bound to the AEAD key
+	if err := recipient.ImportPublicKey(otherPublicKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recipient.Open(sealed, aad); !errors.Is(err,
+		oqs.ErrEnvelopeAuthentication) {
+		t.Errorf("other public key: got %v, want ErrEnvelopeAuthentication",
+			err)
+	}
+
+	var uninitialized oqs.KeyEncapsulation
+	if _, err := uninitialized.Open(sealed, aad); !errors.Is(err,
+		oqs.ErrNotInitialized) {
+		t.Errorf("uninitialized recipient: got %v, want ErrNotInitialized",
+			err)
+	}
+	var keyless oqs.KeyEncapsulation
+	if err := keyless.Init(benchKEMName(t), nil); err != nil {
+		t.Fatal(err)
+	}
+	defer keyless.Clean()
+	if _, err := keyless.Open(sealed, aad); err == nil ||
+		errors.Is(err, oqs.ErrEnvelopeAuthentication) {
+		t.Errorf("recipient without a secret key: got %v, want a "+
+			"configuration error", err)
+	}
+	if _, err := oqs.Seal(benchKEMName(t), publicKey, nil, nil,
+		oqs.WithAEAD(2)); err == nil {
+		t.Error("Seal accepted the reserved AEAD")
+	}
+	if err := recipient.ImportPublicKey(publicKey[1:]); err == nil {
+		t.Error("ImportPublicKey accepted a truncated public key")
+	}
+}
@@ -0,0 +1,106 @@
+package oqstests
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestSelfTestVectors tests that every enabled algorithm passes its
+// known-answer test.
+func TestSelfTestVectors(t *testing.T) {
+	for _, kemName := range oqs.EnabledKEMs() {
+		if err := oqs.SelfTestKEM(kemName); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, sigName := range oqs.EnabledSigs() {
+		if err := oqs.SelfTestSig(sigName); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestSelfTestsInit tests that Init reports the cached self-test result once
+// self-tests are enabled, and that unknown algorithms fail their self-test.
+func TestSelfTestsInit(t *testing.T) {
+	oqs.EnableSelfTests()
+	defer oqs.DisableSelfTests()
+
+	kemName := firstKEMName(t)
+	expected := oqs.SelfTestKEM(kemName)
+	if err := oqs.SelfTestKEM(kemName); err != expected {
+		t.Errorf("self-test result not cached: %v, then %v", expected, err)
+	}
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	if err := kem.Init(kemName, nil); err != expected {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+
+	// Algorithms without a recorded answer are only tested for reproducibility
+	var selfTestErr *oqs.SelfTestError
+	if err := oqs.SelfTestSig("No-Such-Signature"); !errors.As(err,
+		&selfTestErr) || selfTestErr.Test != oqs.SelfTestReproducibility {
+		t.Errorf("expected a reproducibility test failure, got %v", err)
+	}
+}
+
+// TestSelfTestsRandomAlgorithm tests that a self-test run while the "OpenSSL"
+// RNG algorithm is in use is not reported as a failure of the algorithm, nor
+// cached, and that the RNG algorithm can be switched after a self-test.
+func TestSelfTestsRandomAlgorithm(t *testing.T) {
+	if !slices.Contains(oqs.Compatibility().BuildFlags, "OQS_USE_OPENSSL") {
+		t.Skip("liboqs was built without OpenSSL")
+	}
+	kems := oqs.EnabledKEMs()
+	kemName := kems[len(kems)-1]
+	if err := oqs.RandomBytesSwitchAlgorithm("OpenSSL"); err != nil {
+		t.Fatal(err)
+	}
+	var selfTestErr *oqs.SelfTestError
+	err := oqs.SelfTestKEM(kemName)
+	if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+		t.Fatal(err)
+	}
+	if errors.As(err, &selfTestErr) {
+		t.Errorf("RNG algorithm reported as a self-test failure: %v", err)
+	}
+	expected := oqs.SelfTestKEM(kemName)
+	if expected == nil || errors.As(expected, &selfTestErr) {
+		if err := oqs.RandomBytesSwitchAlgorithm("OpenSSL"); err != nil {
+			t.Errorf("can not switch to OpenSSL after a self-test: %v", err)
+		}
+		if err := oqs.RandomBytesSwitchAlgorithm("system"); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		t.Errorf("self-test not run again: %v", expected)
+	}
+}
+
+// TestPairwiseConsistency tests key generation with pairwise consistency
+// checks.
+func TestPairwiseConsistency(t *testing.T) {
+	var kem oqs.KeyEncapsulation
+	defer kem.Clean()
+	_ = kem.Init(firstKEMName(t), nil, oqs.WithPairwiseConsistencyCheck())
+	if _, err := kem.GenerateKeyPair(); err != nil {
+		t.Error(err)
+	}
+
+	var sig oqs.Signature
+	defer sig.Clean()
+	_ = sig.Init(firstSigName(t), nil, oqs.WithPairwiseConsistencyCheck())
+	publicKey, err := sig.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := []byte("This is our favourite message to sign")
+	signature, _ := sig.Sign(msg)
+	if isValid, _ := sig.Verify(msg, signature, publicKey); !isValid {
+		t.Error("signature verification failed")
+	}
+}
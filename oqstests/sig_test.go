@@ -286,6 +286,10 @@ func TestUnsupportedSignature(t *testing.T) {
 
 // TestSignatureWithImportedKey tests the signature with imported key functionality.
 func TestSignatureWithImportedKey(t *testing.T) {
+	if oqs.Backend() != "liboqs" {
+		t.Skip("Dilithium5 is only available with the liboqs backend")
+	}
+
 	// Create a signature object and generate keys
 	sig1 := oqs.Signature{}
 	defer sig1.Clean()
@@ -332,3 +336,106 @@ func TestSignatureWithImportedKey(t *testing.T) {
 		t.Error("Signature verification failed")
 	}
 }
+
+// TestSignatureWithImportedMLDSAKey tests signing with an imported ML-DSA
+// secret key, which every backend with signatures supports.
+func TestSignatureWithImportedMLDSAKey(t *testing.T) {
+	if !oqs.IsSigEnabled("ML-DSA-87") {
+		t.Skip("ML-DSA-87 is not enabled")
+	}
+	sig1 := oqs.Signature{}
+	defer sig1.Clean()
+	if err := sig1.Init("ML-DSA-87", nil); err != nil {
+		t.Fatal(err)
+	}
+	pubKey, err := sig1.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig2 := oqs.Signature{}
+	defer sig2.Clean()
+	if err := sig2.Init("ML-DSA-87", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := sig2.ImportSecretKey(sig1.ExportSecretKey()); err != nil {
+		t.Fatal(err)
+	}
+	message := []byte("test message")
+	signature, err := sig2.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid, err := sig1.Verify(message, signature, pubKey); err != nil ||
+		!valid {
+		t.Errorf("Signature verification failed: %v", err)
+	}
+}
+
+// TestSignatureEmptyMessageAndContext tests signing and verifying empty
+// messages with empty, nil and maximum-length context strings.
+func TestSignatureEmptyMessageAndContext(t *testing.T) {
+	maxContext := make([]byte, oqs.MaxLengthContext)
+	for i := range maxContext {
+		maxContext[i] = byte(i)
+	}
+	tests := []struct {
+		name    string
+		message []byte
+		context []byte
+	}{
+		{"nil message, nil context", nil, nil},
+		{"empty message, nil context", []byte{}, nil},
+		{"empty message, empty context", []byte{}, []byte{}},
+		{"nil message, maximum-length context", nil, maxContext},
+		{"message, maximum-length context", []byte("message"), maxContext},
+		{"empty message, too long context", []byte{}, append(maxContext, 0)},
+	}
+	for _, sigName := range oqs.EnabledSigs() {
+		log.Println("Empty message and context - ", sigName)
+		var signer oqs.Signature
+		if err := signer.Init(sigName, nil); err != nil {
+			t.Fatal(err)
+		}
+		pubKey, err := signer.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature, err := signer.Sign(nil)
+		if err != nil {
+			t.Errorf("%s: can not sign an empty message: %v", sigName, err)
+		} else if isValid, _ := signer.Verify([]byte{}, signature,
+			pubKey); !isValid {
+			t.Errorf("%s: signature of an empty message failed "+
+				"verification", sigName)
+		}
+		for _, tt := range tests {
+			wantErr := len(tt.context) > oqs.MaxLengthContext ||
+				(len(tt.context) > 0 && !signer.Details().SigWithCtxSupport)
+			signature, err := signer.SignWithCtxStr(tt.message, tt.context)
+			if wantErr {
+				if err == nil {
+					t.Errorf("%s: %s: signing should have failed", sigName,
+						tt.name)
+				}
+				if _, err := signer.VerifyWithCtxStr(tt.message, signature,
+					tt.context, pubKey); err == nil {
+					t.Errorf("%s: %s: verification should have failed",
+						sigName, tt.name)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s: %s: %v", sigName, tt.name, err)
+				continue
+			}
+			isValid, err := signer.VerifyWithCtxStr(tt.message, signature,
+				tt.context, pubKey)
+			if err != nil || !isValid {
+				t.Errorf("%s: %s: signature verification failed", sigName,
+					tt.name)
+			}
+		}
+		signer.Clean()
+	}
+}
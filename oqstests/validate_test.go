@@ -0,0 +1,121 @@
+package oqstests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestKEMValidatePublicKey tests the FIPS 203 modulus check on ML-KEM public
+// keys, both directly and through strict mode.
+func TestKEMValidatePublicKey(t *testing.T) {
+	for _, kemName := range oqs.EnabledKEMs() {
+		if !strings.HasPrefix(kemName, "ML-KEM") {
+			continue
+		}
+		var client, server oqs.KeyEncapsulation
+		// Ignore potential errors everywhere
+		_ = client.Init(kemName, nil)
+		_ = server.Init(kemName, nil, oqs.WithStrictValidation())
+		publicKey, _ := client.GenerateKeyPair()
+		if err := client.ValidatePublicKey(publicKey); err != nil {
+			t.Errorf("%s: valid public key rejected: %v", kemName, err)
+		}
+		if _, _, err := server.EncapSecret(publicKey); err != nil {
+			t.Errorf("%s: strict mode rejected a valid public key: %v",
+				kemName, err)
+		}
+		// Set the first 12-bit coefficient to 4095 >= q
+		malformed := append([]byte(nil), publicKey...)
+		malformed[0] = 0xff
+		malformed[1] |= 0x0f
+		if err := client.ValidatePublicKey(malformed); err == nil {
+			t.Errorf("%s: malformed public key accepted", kemName)
+		}
+		if _, _, err := server.EncapSecret(malformed); err == nil {
+			t.Errorf("%s: strict mode accepted a malformed public key",
+				kemName)
+		}
+		// crypto/mlkem always validates public keys, whereas liboqs only does
+		// so in strict mode
+		_, _, err := client.EncapSecret(malformed)
+		if oqs.Backend() == "liboqs" && err != nil {
+			t.Errorf("%s: non-strict mode should not validate public keys",
+				kemName)
+		}
+		if oqs.Backend() != "liboqs" && err == nil {
+			t.Errorf("%s: the %s backend accepted a malformed public key",
+				kemName, oqs.Backend())
+		}
+		if err := client.ValidatePublicKey(publicKey[1:]); err == nil {
+			t.Errorf("%s: truncated public key accepted", kemName)
+		}
+		client.Clean()
+		server.Clean()
+	}
+}
+
+// TestKEMValidateCiphertext tests the ciphertext type check of all enabled
+// KEMs.
+func TestKEMValidateCiphertext(t *testing.T) {
+	for _, kemName := range oqs.EnabledKEMs() {
+		var kem oqs.KeyEncapsulation
+		_ = kem.Init(kemName, nil, oqs.WithStrictValidation())
+		ciphertext := make([]byte, kem.Details().LengthCiphertext)
+		if err := kem.ValidateCiphertext(ciphertext); err != nil {
+			t.Errorf("%s: ciphertext of correct length rejected: %v",
+				kemName, err)
+		}
+		if err := kem.ValidateCiphertext(append(ciphertext, 0)); err == nil {
+			t.Errorf("%s: ciphertext of incorrect length accepted", kemName)
+		}
+		kem.Clean()
+	}
+}
+
+// TestSignatureValidateEncoding tests the ML-DSA and SLH-DSA signature
+// encoding checks, both directly and through strict mode.
+func TestSignatureValidateEncoding(t *testing.T) {
+	msg := []byte("This is our favourite message to sign")
+	for _, sigName := range oqs.EnabledSigs() {
+		isMLDSA := strings.HasPrefix(sigName, "ML-DSA")
+		isSLHDSA := strings.HasPrefix(sigName, "SLH_DSA") ||
+			strings.HasPrefix(sigName, "SPHINCS+")
+		if !isMLDSA && !isSLHDSA {
+			continue
+		}
+		var signer, verifier oqs.Signature
+		// Ignore potential errors everywhere
+		_ = signer.Init(sigName, nil)
+		_ = verifier.Init(sigName, nil, oqs.WithStrictValidation())
+		pubKey, _ := signer.GenerateKeyPair()
+		signature, _ := signer.Sign(msg)
+		if err := verifier.ValidateSignatureEncoding(signature); err != nil {
+			t.Errorf("%s: valid signature rejected: %v", sigName, err)
+		}
+		if isValid, err := verifier.Verify(msg, signature, pubKey); !isValid ||
+			err != nil {
+			t.Errorf("%s: strict verification failed: %v", sigName, err)
+		}
+		truncated := signature[:len(signature)-1]
+		if err := verifier.ValidateSignatureEncoding(truncated); err == nil {
+			t.Errorf("%s: truncated signature accepted", sigName)
+		}
+		if _, err := verifier.Verify(msg, truncated, pubKey); err == nil {
+			t.Errorf("%s: strict mode accepted a truncated signature",
+				sigName)
+		}
+		if isMLDSA {
+			// The last byte counts the hints of the last row, which can
+			// never exceed the maximum number of hints omega < 255
+			malformed := append([]byte(nil), signature...)
+			malformed[len(malformed)-1] = 0xff
+			if err := verifier.ValidateSignatureEncoding(malformed); err == nil {
+				t.Errorf("%s: malformed hint encoding accepted", sigName)
+			}
+		}
+		signer.Clean()
+		verifier.Clean()
+	}
+}
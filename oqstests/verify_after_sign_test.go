@@ -0,0 +1,49 @@
+package oqstests
+
+import (
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestVerifyAfterSign tests signing with verify-after-sign, with a generated
+// and with an imported key pair.
+func TestVerifyAfterSign(t *testing.T) {
+	sigName := firstSigName(t)
+	var signer oqs.Signature
+	defer signer.Clean()
+	_ = signer.Init(sigName, nil, oqs.WithVerifyAfterSign())
+	publicKey, _ := signer.GenerateKeyPair()
+	msg := []byte("This is our favourite message to sign")
+	signature, err := signer.Sign(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isValid, _ := signer.Verify(msg, signature, publicKey); !isValid {
+		t.Error("signature verification failed")
+	}
+	if signer.Details().SigWithCtxSupport {
+		if _, err := signer.SignWithCtxStr(msg, []byte("ctx")); err != nil {
+			t.Error(err)
+		}
+	}
+	if _, errs := signer.SignBatch([][]byte{msg, msg}); errs[0] != nil ||
+		errs[1] != nil {
+		t.Errorf("batch signing failed: %v", errs)
+	}
+
+	var imported oqs.Signature
+	defer imported.Clean()
+	_ = imported.Init(sigName, signer.ExportSecretKey(),
+		oqs.WithVerifyAfterSign())
+	if _, err := imported.Sign(msg); err == nil {
+		t.Error("signed without the public key")
+	}
+	if err := imported.ImportPublicKey(publicKey[1:]); err == nil {
+		t.Error("short public key accepted")
+	}
+	_ = imported.ImportPublicKey(publicKey)
+	if _, err := imported.Sign(msg); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,96 @@
+package oqstests
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/open-quantum-safe/liboqs-go/oqs"
+)
+
+// TestParseVersion tests the parsing of liboqs version strings.
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		text string
+		want oqs.Version
+		ok   bool
+	}{
+		{"0.13.0", oqs.Version{Major: 0, Minor: 13, Patch: 0}, true},
+		{"0.13.1-dev", oqs.Version{Major: 0, Minor: 13, Patch: 1,
+			PreRelease: "dev"}, true},
+		{"1.2.3-rc1+build.5", oqs.Version{Major: 1, Minor: 2, Patch: 3,
+			PreRelease: "rc1"}, true},
+		{"0.13", oqs.Version{}, false},
+		{"0.x.0", oqs.Version{}, false},
+		{"", oqs.Version{}, false},
+	}
+	for _, tt := range tests {
+		got, err := oqs.ParseVersion(tt.text)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseVersion(%q) = %v, %v", tt.text, got, err)
+		}
+		if tt.ok && !strings.Contains(tt.text, "+") &&
+			got.String() != tt.text {
+			t.Errorf("%q.String() = %q", tt.text, got.String())
+		}
+	}
+}
+
+// TestABICompatible tests the compatibility rules of header and library
+// versions.
+func TestABICompatible(t *testing.T) {
+	tests := []struct {
+		header, library string
+		want            bool
+	}{
+		{"0.13.0", "0.13.0", true},
+		{"0.13.0", "0.13.1-dev", true},
+		{"0.13.0", "0.12.0", false},
+		{"0.12.0", "0.13.0", false},
+		{"1.2.0", "1.3.0", true},
+		{"1.2.0", "1.1.0", false},
+		{"1.0.0", "2.0.0", false},
+	}
+	for _, tt := range tests {
+		header, _ := oqs.ParseVersion(tt.header)
+		library, _ := oqs.ParseVersion(tt.library)
+		got, reason := header.ABICompatible(library)
+		if got != tt.want || (got == (reason != "")) {
+			t.Errorf("%s.ABICompatible(%s) = %v, %q", tt.header, tt.library,
+				got, reason)
+		}
+	}
+}
+
+// TestCompatibilityReport tests the compatibility report of the library in
+// use.
+func TestCompatibilityReport(t *testing.T) {
+	report := oqs.Compatibility()
+	if report.Backend != oqs.Backend() {
+		t.Errorf("backend %q, want %q", report.Backend, oqs.Backend())
+	}
+	if !report.Compatible {
+		t.Fatalf("incompatible liboqs: %v",
+			&oqs.CompatibilityError{Report: report})
+	}
+	if oqs.Backend() != "liboqs" {
+		if report.HeaderVersion != (oqs.Version{}) {
+			t.Errorf("header version %v without liboqs", report.HeaderVersion)
+		}
+		return
+	}
+	library, err := oqs.ParseVersion(oqs.LiboqsVersion())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.LibraryVersion != library {
+		t.Errorf("library version %v, want %v", report.LibraryVersion, library)
+	}
+
+	var compatErr *oqs.CompatibilityError
+	var kem oqs.KeyEncapsulation
+	if err := kem.Init(firstKEMName(t), nil); errors.As(err, &compatErr) {
+		t.Errorf("Init failed with a compatible library: %v", err)
+	}
+	kem.Clean()
+}